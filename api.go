@@ -1,30 +1,218 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/sahaj-b/wakafetch/types"
+	"github.com/sahaj-b/wakafetch/ui"
 )
 
-func fetchSummary(apiKey, apiURL string, days int) (*types.SummaryResponse, error) {
+// unavailableError means the server couldn't be reached or is down, as opposed to rejecting the request,
+// in which case stale cached data is better than nothing
+type unavailableError struct{ msg string }
+
+func (e unavailableError) Error() string { return e.msg }
+
+const defaultTimeout = 10 * time.Second
+
+var httpClient = &http.Client{Timeout: defaultTimeout}
+
+// configureHTTPClient applies the proxy, TLS and timeout settings from .wakatime.cfg
+func configureHTTPClient(cfg fileConfig) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.proxy != "" {
+		if strings.Contains(cfg.proxy, `\`) && !strings.Contains(cfg.proxy, "://") {
+			return fmt.Errorf("NTLM proxies (domain\\user:pass) aren't supported, use an http(s):// or socks5:// proxy")
+		}
+		proxy := cfg.proxy
+		if !strings.Contains(proxy, "://") {
+			proxy = "http://" + proxy
+		}
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("Invalid proxy in config: '%s'", cfg.proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.sslCertsFile != "" || cfg.noSSLVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: cfg.noSSLVerify}
+		if cfg.sslCertsFile != "" {
+			pem, err := os.ReadFile(cfg.sslCertsFile)
+			if err != nil {
+				return fmt.Errorf("failed to read ssl_certs_file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("No PEM certificates found in ssl_certs_file: %s", cfg.sslCertsFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	httpClient = &http.Client{Transport: transport, Timeout: defaultTimeout}
+	if cfg.timeout > 0 {
+		httpClient.Timeout = cfg.timeout
+	}
+	return nil
+}
+
+// fetchSummary fetches the days from start to end, optionally only for one project (empty for all)
+func fetchSummary(apiKey, apiURL string, start, end time.Time, project string) (*types.SummaryResponse, error) {
+	today := truncateToDay(time.Now())
+	days := make(map[string]types.DayData)
+	stale := make(map[string]types.DayData)
+	var missing []time.Time
+
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateLayout)
+		entry, found := readCache(dayCacheKey(apiKey, apiURL, date, project))
+		var day types.DayData
+		if found && json.Unmarshal(entry.Body, &day) != nil {
+			found = false
+		}
+		// a day is final once it was fetched after it ended
+		final := d.Before(today) && entry.FetchedAt.After(d.AddDate(0, 0, 1))
+		switch {
+		case found && (cacheOpts.offline || (!cacheOpts.refresh && (final || entry.fresh()))):
+			days[date] = day
+		case found:
+			stale[date] = day
+			missing = append(missing, d)
+		default:
+			missing = append(missing, d)
+		}
+	}
+
+	if len(missing) > 0 && cacheOpts.offline {
+		if len(days) == 0 {
+			return nil, fmt.Errorf("No cached data for this range (offline mode)")
+		}
+		ui.Warnln("No cached data for %d of the requested days (offline mode)", len(missing))
+		missing = nil
+	}
+
+	if len(missing) > 0 {
+		// days in between that are already cached get re-fetched too, one request beats many
+		first, last := missing[0], missing[len(missing)-1]
+		requestURL := summaryURL(apiURL, first, last, project)
+		body, err := fetchBody(apiKey, requestURL)
+		var unavailable unavailableError
+		if errors.As(err, &unavailable) && !cacheOpts.noStale && len(stale)+len(days) > 0 {
+			ui.Warnln("%s. Showing cached data", err.Error())
+			for date, day := range stale {
+				days[date] = day
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to fetch stats: %w", err)
+		} else {
+			var response types.SummaryResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				return nil, fmt.Errorf("Invalid response from server (failed to decode JSON)")
+			}
+			for _, day := range response.Data {
+				date := dayDate(day)
+				days[date] = day
+				if raw, err := json.Marshal(day); err == nil {
+					writeCache(dayCacheKey(apiKey, apiURL, date, project), requestURL, raw)
+				}
+			}
+		}
+	}
+
+	return buildSummaryResponse(days, start, end), nil
+}
+
+func summaryURL(apiURL string, start, end time.Time, project string) string {
 	apiURL = strings.TrimSuffix(apiURL, "/")
-	today := time.Now()
-	todayDate := today.Format("2006-01-02")
-	startDate := today.AddDate(0, 0, -days+1).Format("2006-01-02")
-	requestURL := fmt.Sprintf("%s/compat/wakatime/v1/users/current/summaries?start=%s&end=%s", apiURL, startDate, todayDate)
+	startDate := start.Format("2006-01-02")
+	endDate := end.Format("2006-01-02")
+	requestURL := fmt.Sprintf("%s/compat/wakatime/v1/users/current/summaries?start=%s&end=%s", apiURL, startDate, endDate)
 	if strings.HasSuffix(apiURL, "/v1") {
-		requestURL = fmt.Sprintf("%s/users/current/summaries?start=%s&end=%s", apiURL, startDate, todayDate)
+		requestURL = fmt.Sprintf("%s/users/current/summaries?start=%s&end=%s", apiURL, startDate, endDate)
 	}
-	response, err := fetchApi[types.SummaryResponse](apiKey, requestURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch stats: %w", err)
+	if project != "" {
+		requestURL += "&project=" + url.QueryEscape(project)
 	}
-	return response, nil
+	return requestURL
+}
+
+func dayCacheKey(apiKey, apiURL, date, project string) string {
+	id := "summaries|" + strings.TrimSuffix(apiURL, "/") + "|" + date
+	if project != "" {
+		id += "|project=" + project
+	}
+	return cacheKey(apiKey, id)
+}
+
+// dayDate is the YYYY-MM-DD date of a day in the summaries response
+func dayDate(day types.DayData) string {
+	if day.Range.Date != "" {
+		return day.Range.Date
+	}
+	return strings.Split(day.Range.Start, "T")[0]
+}
+
+// buildSummaryResponse stitches cached and fetched days back into a single response, recomputing the totals
+func buildSummaryResponse(days map[string]types.DayData, start, end time.Time) *types.SummaryResponse {
+	response := &types.SummaryResponse{
+		Data:  make([]types.DayData, 0, len(days)),
+		Start: start.Format(time.RFC3339),
+		End:   end.Add(24*time.Hour - time.Second).Format(time.RFC3339),
+	}
+
+	total := 0.0
+	activeDays := 0
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		day, ok := days[d.Format(dateLayout)]
+		if !ok {
+			continue
+		}
+		response.Data = append(response.Data, day)
+		total += day.GrandTotal.TotalSeconds
+		if ui.IsActiveDay(day) {
+			activeDays++
+		}
+	}
+
+	response.CumulativeTotal.Seconds = total
+	response.CumulativeTotal.Digital = digitalFmt(total)
+	response.CumulativeTotal.Text = textFmt(total)
+
+	avg := response.DailyAverage
+	avg.DaysIncludingHolidays = len(response.Data)
+	avg.DaysMinusHolidays = activeDays
+	avg.Holidays = len(response.Data) - activeDays
+	if activeDays > 0 {
+		avg.Seconds = total / float64(activeDays)
+	}
+	avg.Text = textFmt(avg.Seconds)
+	response.DailyAverage = avg
+
+	return response
+}
+
+func digitalFmt(seconds float64) string {
+	sec := int(seconds)
+	return fmt.Sprintf("%d:%02d", sec/3600, (sec%3600)/60)
+}
+
+func textFmt(seconds float64) string {
+	sec := int(seconds)
+	return fmt.Sprintf("%d hrs %d mins", sec/3600, (sec%3600)/60)
 }
 
 func fetchStats(apiKey, apiURL, rangeStr string) (*types.StatsResponse, error) {
@@ -34,55 +222,139 @@ func fetchStats(apiKey, apiURL, rangeStr string) (*types.StatsResponse, error) {
 		requestURL = fmt.Sprintf("%s/users/current/stats/%s", apiURL, rangeStr)
 	}
 	// fmt.Println(requestURL)
-	response, err := fetchApi[types.StatsResponse](apiKey, requestURL)
+	response, err := fetchCached[types.StatsResponse](apiKey, requestURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch stats: %w", err)
 	}
 	return response, nil
 }
 
-func fetchApi[T any](apiKey, requestURL string) (*T, error) {
-	const timeout = 10 * time.Second
+// fetchDurations fetches the coding sessions of a day, optionally only for one project.
+// Like the summaries, a day that was fetched after it ended doesn't change anymore
+func fetchDurations(apiKey, apiURL string, date time.Time, project string) (*types.DurationsResponse, error) {
+	requestURL := durationsURL(apiURL, date, project)
+	entry, found := readCache(cacheKey(apiKey, requestURL))
+	final := date.Before(truncateToDay(time.Now())) && entry.FetchedAt.After(date.AddDate(0, 0, 1))
+	var cached types.DurationsResponse
+	if found && final && !cacheOpts.refresh && json.Unmarshal(entry.Body, &cached) == nil {
+		return &cached, nil
+	}
+	response, err := fetchCached[types.DurationsResponse](apiKey, requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch durations: %w", err)
+	}
+	return response, nil
+}
+
+func durationsURL(apiURL string, date time.Time, project string) string {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	requestURL := fmt.Sprintf("%s/compat/wakatime/v1/users/current/durations?date=%s", apiURL, date.Format(dateLayout))
+	if strings.HasSuffix(apiURL, "/v1") {
+		requestURL = fmt.Sprintf("%s/users/current/durations?date=%s", apiURL, date.Format(dateLayout))
+	}
+	if project != "" {
+		requestURL += "&project=" + url.QueryEscape(project)
+	}
+	return requestURL
+}
+
+// fetchCached serves a whole response from cache while it's fresh, falling back to stale entries if the server is unreachable
+func fetchCached[T any](apiKey, requestURL string) (*T, error) {
+	key := cacheKey(apiKey, requestURL)
+	entry, found := readCache(key)
+
+	body := []byte(entry.Body)
+	switch {
+	case found && (cacheOpts.offline || (!cacheOpts.refresh && entry.fresh())):
+	case cacheOpts.offline:
+		return nil, fmt.Errorf("No cached data for this request (offline mode)")
+	default:
+		fetched, err := fetchBody(apiKey, requestURL)
+		var unavailable unavailableError
+		if errors.As(err, &unavailable) && !cacheOpts.noStale && found {
+			ui.Warnln("%s. Showing cached data from %s", err.Error(), entry.FetchedAt.Format("Jan 2 15:04"))
+		} else if err != nil {
+			return nil, err
+		} else {
+			body = fetched
+			writeCache(key, requestURL, body)
+		}
+	}
+
+	var apiResponse T
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return nil, fmt.Errorf("Invalid response from server (failed to decode JSON)")
+	}
+	return &apiResponse, nil
+}
+
+func fetchBody(apiKey, requestURL string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, status, retryAfter, err := fetchOnce(apiKey, requestURL)
+		if err == nil {
+			return body, nil
+		}
+
+		var unavailable unavailableError
+		if !errors.As(err, &unavailable) && !isRetryableStatus(status) {
+			return nil, err
+		}
+		wait, ok := retryOpts.backoff(attempt, retryAfter)
+		if !ok {
+			return nil, err
+		}
+		ui.Warnln("%s. Retrying in %s (%d/%d)", err.Error(), wait.Round(100*time.Millisecond), attempt+1, retryOpts.maxRetries)
+		time.Sleep(wait)
+	}
+}
+
+// fetchOnce makes a single request, returning the status code and Retry-After delay alongside any error
+func fetchOnce(apiKey, requestURL string) ([]byte, int, time.Duration, error) {
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	encodedKey := base64.StdEncoding.EncodeToString([]byte(apiKey))
 	req.Header.Set("Authorization", "Basic "+encodedKey)
 
-	client := &http.Client{Timeout: timeout}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
-			return nil, fmt.Errorf("Request timed out after %s while contacting server", timeout)
+			return nil, 0, 0, unavailableError{fmt.Sprintf("Request timed out after %s while contacting server", httpClient.Timeout)}
 		}
-		return nil, fmt.Errorf("Unable to reach server. Check your internet connection")
+		return nil, 0, 0, unavailableError{"Unable to reach server. Check your internet connection"}
 	}
 
 	defer resp.Body.Close()
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
 
 	if resp.StatusCode != http.StatusOK {
 		switch resp.StatusCode {
+		case http.StatusAccepted:
+			// wakatime is still calculating the stats, the body is incomplete
+			return nil, resp.StatusCode, retryAfter, unavailableError{"Stats are still being calculated by the server (202). Please try again in a bit"}
 		case http.StatusUnauthorized:
-			return nil, fmt.Errorf("Authentication failed (401). Check your API key")
+			return nil, resp.StatusCode, 0, fmt.Errorf("Authentication failed (401). Check your API key")
 		case http.StatusForbidden:
-			return nil, fmt.Errorf("Access forbidden (403). Your API key might not have permission")
+			return nil, resp.StatusCode, 0, fmt.Errorf("Access forbidden (403). Your API key might not have permission")
 		case http.StatusNotFound:
-			return nil, fmt.Errorf("Endpoint not found (404). Verify the API URL")
+			return nil, resp.StatusCode, 0, fmt.Errorf("Endpoint not found (404). Verify the API URL")
 		case http.StatusTooManyRequests:
-			return nil, fmt.Errorf("Rate limit exceeded (429). Please try again later")
+			return nil, resp.StatusCode, retryAfter, fmt.Errorf("Rate limit exceeded (429). Please try again later")
 		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
-			return nil, fmt.Errorf("Server unavailable (%s). Please try again later", resp.Status)
+			return nil, resp.StatusCode, retryAfter, unavailableError{fmt.Sprintf("Server unavailable (%s). Please try again later", resp.Status)}
 		default:
-			return nil, fmt.Errorf("Api request failed: %s", resp.Status)
+			return nil, resp.StatusCode, 0, fmt.Errorf("Api request failed: %s", resp.Status)
 		}
 	}
 
-	var apiResponse T
-	if err = json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return nil, fmt.Errorf("Invalid response from server (failed to decode JSON)")
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, 0, unavailableError{"Connection dropped while reading the response"}
 	}
-
-	return &apiResponse, nil
+	if !json.Valid(body) {
+		return nil, resp.StatusCode, 0, fmt.Errorf("Invalid response from server (failed to decode JSON)")
+	}
+	return body, resp.StatusCode, 0, nil
 }
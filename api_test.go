@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers with the statuses in order (the last one repeating), Retry-After on the ones that have it
+type scriptedResponse struct {
+	status     int
+	retryAfter string
+}
+
+func scriptedServer(t *testing.T, script ...scriptedResponse) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1)) - 1
+		resp := script[min(n, len(script)-1)]
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.WriteHeader(resp.status)
+		if resp.status == http.StatusOK {
+			w.Write([]byte(`{"data":{}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func withRetries(t *testing.T, p retryPolicy) {
+	t.Helper()
+	saved := retryOpts
+	retryOpts = p
+	t.Cleanup(func() { retryOpts = saved })
+}
+
+func TestFetchBodyPendingThenOK(t *testing.T) {
+	withRetries(t, retryPolicy{maxRetries: 3, maxWait: 20 * time.Millisecond})
+	srv, requests := scriptedServer(t, scriptedResponse{status: http.StatusAccepted}, scriptedResponse{status: http.StatusOK})
+
+	if _, err := fetchBody("key", srv.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("got %d requests, want 2", n)
+	}
+}
+
+func TestFetchBodyHonoursRetryAfter(t *testing.T) {
+	withRetries(t, retryPolicy{maxRetries: 3, maxWait: 5 * time.Second})
+	srv, requests := scriptedServer(t, scriptedResponse{status: http.StatusServiceUnavailable, retryAfter: "1"}, scriptedResponse{status: http.StatusOK})
+
+	start := time.Now()
+	if _, err := fetchBody("key", srv.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("retried after %s, before Retry-After", elapsed)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("got %d requests, want 2", n)
+	}
+}
+
+func TestFetchBodyRetryAfterTooLong(t *testing.T) {
+	withRetries(t, retryPolicy{maxRetries: 3, maxWait: time.Second})
+	srv, requests := scriptedServer(t, scriptedResponse{status: http.StatusTooManyRequests, retryAfter: "120"})
+
+	if _, err := fetchBody("key", srv.URL); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("got %d requests, want 1", n)
+	}
+}
+
+func TestFetchBodyUnauthorizedNotRetried(t *testing.T) {
+	withRetries(t, retryPolicy{maxRetries: 3, maxWait: 20 * time.Millisecond})
+	srv, requests := scriptedServer(t, scriptedResponse{status: http.StatusUnauthorized})
+
+	if _, err := fetchBody("key", srv.URL); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("got %d requests, want 1", n)
+	}
+}
+
+func TestFetchBodyRetriesRunOut(t *testing.T) {
+	withRetries(t, retryPolicy{maxRetries: 2, maxWait: 20 * time.Millisecond})
+	srv, requests := scriptedServer(t, scriptedResponse{status: http.StatusBadGateway})
+
+	if _, err := fetchBody("key", srv.URL); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := requests.Load(); n != 3 {
+		t.Fatalf("got %d requests, want 3 (1 + 2 retries)", n)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// responses are cached on disk so repeated runs (shell prompts, etc) don't hit the network every time
+// - /stats responses are cached whole, keyed by request URL, and expire after the TTL
+// - /summaries responses are split into days, past days never change once they're over,
+//   so only today (and days fetched before they ended) are subject to the TTL
+
+type cacheOptions struct {
+	ttl     time.Duration
+	offline bool // only read from cache, never hit the network
+	refresh bool // ignore cached entries, but still update them
+	noStale bool // fail when the server is unreachable instead of falling back to stale entries
+}
+
+var cacheOpts = cacheOptions{ttl: 5 * time.Minute}
+
+type cacheEntry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	URL       string          `json:"url"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// refreshEvery caps the ttl at the interval of a command that refreshes on its own, so no refresh
+// gets served the previous one's responses
+func refreshEvery(interval time.Duration) {
+	cacheOpts.ttl = min(cacheOpts.ttl, interval)
+}
+
+func (e cacheEntry) fresh() bool {
+	return time.Since(e.FetchedAt) < cacheOpts.ttl
+}
+
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wakafetch"), nil
+}
+
+// cacheKey never includes the raw API key, only its hash
+func cacheKey(apiKey, id string) string {
+	keyHash := sha256.Sum256([]byte(apiKey))
+	sum := sha256.Sum256([]byte(hex.EncodeToString(keyHash[:]) + "|" + id))
+	return hex.EncodeToString(sum[:])
+}
+
+func readCache(key string) (cacheEntry, bool) {
+	var entry cacheEntry
+	dir, err := cacheDir()
+	if err != nil {
+		return entry, false
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// writeCache is best effort, a read-only or missing cache dir just means no caching
+func writeCache(key, url string, body []byte) {
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return
+	}
+	raw, err := json.Marshal(cacheEntry{FetchedAt: time.Now(), URL: url, Body: body})
+	if err != nil {
+		return
+	}
+	// write to a temp file and rename, so concurrent runs never see a half written entry
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), filepath.Join(dir, key+".json"))
+}
+
+// cachedProjects lists every project name found in cached responses, for shell completion.
+// Entries of every account are read, since the cache doesn't know which key wrote them
+func cachedProjects() []string {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var entry cacheEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			continue
+		}
+
+		var projects []types.StatItem
+		if strings.Contains(entry.URL, "/summaries") {
+			var day types.DayData
+			if json.Unmarshal(entry.Body, &day) == nil {
+				projects = day.Projects
+			}
+		} else {
+			var stats types.StatsResponse
+			if json.Unmarshal(entry.Body, &stats) == nil {
+				projects = stats.Data.Projects
+			}
+		}
+		for _, p := range projects {
+			seen[p.Name] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+type command struct {
+	name        string
+	args        string   // positional args, for the usage line
+	argValues   []string // positional args, for completion
+	description string
+	hidden      bool // left out of help and completion
+	register    func(c *Config)
+	run         func(c Config)
+}
+
+// commands[0] is the bare `wakafetch`, which keeps the old flat flags (--daily, --heatmap) working
+var commands []command
+
+// registerStatsFlags is shared by the bare command and `stats`, they're the same command
+func registerStatsFlags(c *Config) {
+	c.rangeFlags()
+	c.fullFlag = c.boolFlag("full", "f", false, "Display full statistics")
+	c.compareFlag = c.boolFlag("compare", "C", false, "Show the change since the previous period of the same length")
+	c.hoursFlag = c.boolFlag("hours", "", false, "Add a By Hour card, one request per day so ranges of up to 31 days")
+	c.dailyFlag = c.boolFlag("daily", "D", false, "Display daily breakdown")
+	c.heatmapFlag = c.boolFlag("heatmap", "H", false, "Display heatmap of daily activity")
+	c.accountFlags()
+	c.colorFlag()
+	c.heatmapFlags()
+	c.outputFlags()
+	c.minActiveFlag = c.durationFlag("min-active", "", time.Minute, "Least coding time for a day to count as active (default: 1m)")
+	c.watchFlag = c.durationFlag("watch", "W", 0, "Refresh the view in place every interval, e.g. 60s")
+	c.fetchFlags()
+	c.helpFlags()
+}
+
+// assigned in init, since `completion` and help need to loop over commands
+func init() {
+	commands = []command{
+		{
+			name:     "",
+			register: registerStatsFlags,
+			run:      runStats,
+		},
+		{
+			name:        "stats",
+			description: "Show stats for a range (default)",
+			register:    registerStatsFlags,
+			run:         runStats,
+		},
+		{
+			name:        "daily",
+			description: "Show a day by day breakdown",
+			register: func(c *Config) {
+				c.rangeFlags()
+				c.accountFlags()
+				c.colorFlag()
+				c.heatmapFlags()
+				c.outputFlags()
+				c.minActiveFlag = c.durationFlag("min-active", "", time.Minute, "Least coding time for a day to count as active (default: 1m)")
+				c.watchFlag = c.durationFlag("watch", "W", 0, "Refresh the view in place every interval, e.g. 60s")
+				c.fetchFlags()
+				c.helpFlags()
+			},
+			run: func(c Config) {
+				*c.dailyFlag = true
+				runStats(c)
+			},
+		},
+		{
+			name:        "heatmap",
+			description: "Show a heatmap of daily activity",
+			register: func(c *Config) {
+				c.rangeFlags()
+				c.accountFlags()
+				c.colorFlag()
+				c.heatmapFlags()
+				c.outputFlags()
+				c.watchFlag = c.durationFlag("watch", "W", 0, "Refresh the view in place every interval, e.g. 60s")
+				c.fetchFlags()
+				c.helpFlags()
+			},
+			run: func(c Config) {
+				*c.heatmapFlag = true
+				runStats(c)
+			},
+		},
+		{
+			name:        "export",
+			description: "Export raw data as JSON, CSV or TSV",
+			register: func(c *Config) {
+				c.rangeFlags()
+				c.dailyFlag = c.boolFlag("daily", "D", false, "Export per-day summaries instead of range totals")
+				c.formatFlag = c.stringFlag("format", "", "json", "Export format (json/csv/tsv) (default: json)")
+				c.outputFlag = c.stringFlag("output", "O", "", "Write to a file instead of stdout")
+				c.htmlFlag = c.stringFlag("html", "", "", "Write an HTML report with the cards and heatmap to a file")
+				c.heatmapFlags()
+				c.accountFlags()
+				c.fetchFlags()
+				c.helpFlags()
+			},
+			run: runExport,
+		},
+		{
+			name:        "goals",
+			description: "Show progress on the goals from the [goals] config section",
+			register: func(c *Config) {
+				// not --days, that's a range and `days` in the config would set it too
+				c.historyFlag = c.intFlag("history", "", 28, "Days of history for streaks, from the monday of that week (default: 28)")
+				c.minActiveFlag = c.durationFlag("min-active", "", time.Minute, "Least coding time for a day to count as active (default: 1m)")
+				c.accountFlags()
+				c.colorFlag()
+				c.fetchFlags()
+				c.helpFlags()
+			},
+			run: runGoals,
+		},
+		{
+			name:        "timeline",
+			description: "Show a day's coding sessions on a 24h timeline",
+			register: func(c *Config) {
+				c.dateFlag = c.stringFlag("date", "", "", "Day to show, YYYY-MM-DD (default: today)")
+				c.accountFlags()
+				c.colorFlag()
+				c.fetchFlags()
+				c.helpFlags()
+			},
+			run: runTimeline,
+		},
+		{
+			name:        "tui",
+			description: "Browse stats interactively, drilling into projects",
+			register: func(c *Config) {
+				c.rangeFlag = c.stringFlag("range", "r", "7d", "Range to start with (today/7d/30d/6m/1y) (default: 7d)")
+				c.accountFlags()
+				c.colorFlag()
+				c.heatmapFlags()
+				c.fetchFlags()
+				c.helpFlags()
+			},
+			run: runTUI,
+		},
+		{
+			name:        "serve",
+			description: "Serve stats as Prometheus metrics",
+			register: func(c *Config) {
+				c.metricsFlag = c.stringFlag("metrics", "", ":9184", "Address to serve /metrics on (default: :9184)")
+				c.intervalFlag = c.durationFlag("interval", "i", 5*time.Minute, "How often to refresh the metrics (default: 5m)")
+				c.rangesFlag = c.stringFlag("ranges", "", "today,7d", "Comma separated ranges for wakafetch_seconds_total (default: today,7d)")
+				c.dailyDaysFlag = c.intFlag("daily-days", "", 7, "Days to expose in wakafetch_daily_seconds (default: 7)")
+				c.accountFlags()
+				c.fetchFlags()
+				c.helpFlags()
+			},
+			run: runServe,
+		},
+		{
+			name:        "config",
+			description: "Show config files, profiles and settings in use",
+			register: func(c *Config) {
+				c.configFlag = c.stringFlag("config", "c", "", "Path to .wakatime.cfg (default: $WAKATIME_HOME/.wakatime.cfg or ~/.wakatime.cfg)")
+				c.colorFlag()
+				c.helpFlags()
+			},
+			run: runConfig,
+		},
+		{
+			name:        "cache",
+			args:        "[info|path|clear]",
+			argValues:   []string{"info", "path", "clear"},
+			description: "Inspect or clear the response cache",
+			register: func(c *Config) {
+				c.colorFlag()
+				c.helpFlags()
+			},
+			run: runCache,
+		},
+		{
+			name:        "completion",
+			args:        "bash|zsh|fish",
+			argValues:   []string{"bash", "zsh", "fish"},
+			description: "Print a shell completion script",
+			register: func(c *Config) {
+				c.helpFlags()
+			},
+			run: runCompletion,
+		},
+		{
+			// used by the completion scripts: `wakafetch __complete profiles|projects`
+			name:     "__complete",
+			hidden:   true,
+			register: func(c *Config) {},
+			run:      runComplete,
+		},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands[1:] {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func runStats(config Config) {
+	if len(config.args) > 0 {
+		ui.Errorln("Unknown command: '%s'. Run `wakafetch --help` for a list of commands", config.args[0])
+	}
+	profiles := setupAPI(config)
+
+	if *config.watchFlag != 0 {
+		watchView(config, profiles)
+		return
+	}
+
+	v, err := fetchView(config, profiles)
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+	outputView(config, v.data, v.display)
+}
+
+func runExport(config Config) {
+	profiles := setupAPI(config)
+
+	if *config.htmlFlag != "" {
+		if *config.outputFlag != "" || config.isSet("format") {
+			ui.Errorln("--html can't be used with --output or --format")
+		}
+		exportHTML(config, profiles)
+		return
+	}
+
+	var data any
+	var err error
+	if shouldUseSummaryAPI(config) {
+		dr := summaryDateRange(config)
+		data, err = fetchSummaryAll(profiles, dr.start, dr.end, *config.projectFlag)
+	} else {
+		data, err = fetchStatsAll(profiles, getRangeStr(*config.rangeFlag))
+	}
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+
+	out := os.Stdout
+	if *config.outputFlag != "" {
+		out, err = os.Create(*config.outputFlag)
+		if err != nil {
+			ui.Errorln("Failed to create output file: %s", err.Error())
+		}
+		defer out.Close()
+	}
+	outputFormatted(out, *config.formatFlag, data)
+}
+
+// exportHTML always shows the full cards. The heatmap needs daily data,
+// so only all time stats (which /summaries can't do) come without it
+func exportHTML(config Config, profiles []apiProfile) {
+	var payload *ui.DisplayPayload
+	var days []types.DayData
+	if shouldUseSummaryAPI(config) || getRangeStr(*config.rangeFlag) != "all_time" {
+		dr := summaryDateRange(config)
+		data, err := fetchSummaryAll(profiles, dr.start, dr.end, *config.projectFlag)
+		if err != nil {
+			ui.Errorln(err.Error())
+		}
+		if *config.projectFlag != "" {
+			payload = ui.ProjectPayload(data, *config.projectFlag, dr.label)
+		} else {
+			payload = ui.SummaryPayload(data, true, dr.label)
+		}
+		if payload != nil {
+			days = data.Data
+		}
+	} else {
+		data, err := fetchStatsAll(profiles, "all_time")
+		if err != nil {
+			ui.Errorln(err.Error())
+		}
+		payload = ui.StatsPayload(data, true, "all_time")
+	}
+	if payload == nil {
+		ui.Errorln("No data available for the selected period")
+	}
+
+	out, err := os.Create(*config.htmlFlag)
+	if err != nil {
+		ui.Errorln("Failed to create report: %s", err.Error())
+	}
+	defer out.Close()
+	if err := ui.WriteHTML(out, payload, days); err != nil {
+		ui.Errorln("Failed to write report: %s", err.Error())
+	}
+	fmt.Printf("Wrote report to %s\n", *config.htmlFlag)
+}
+
+func runConfig(config Config) {
+	printField := func(key, val string) {
+		fmt.Printf("%s%-18s%s%s\n", ui.Clr.BoldBlue, key, ui.Clr.Reset, val)
+	}
+	foundStr := func(path string) string {
+		if fileExists(path) {
+			return path
+		}
+		return path + ui.Clr.Gray + " (not found)" + ui.Clr.Reset
+	}
+
+	printField("Config", foundStr(getConfigPath(*config.configFlag)))
+	printField("wakafetch config", foundStr(getWakafetchConfigPath()))
+	if dir, err := cacheDir(); err == nil {
+		printField("Cache", dir)
+	}
+
+	fileCfg, err := parseConfig(*config.configFlag)
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Clr.Bold + "Profiles:" + ui.Clr.Reset)
+	for _, p := range append([]profileConfig{fileCfg.defaultProfile}, fileCfg.profiles...) {
+		apiURL := p.apiURL
+		if apiURL == "" {
+			apiURL = ui.Clr.Red + "api_url missing" + ui.Clr.Reset
+		}
+		fmt.Printf("  %s%-12s%s %s %s\n", ui.Clr.Green, p.name, ui.Clr.Reset, apiURL, ui.Clr.Gray+describeKeySource(p)+ui.Clr.Reset)
+	}
+
+	if len(fileCfg.wakafetch) > 0 {
+		fmt.Println()
+		fmt.Println(ui.Clr.Bold + "Defaults [wakafetch]:" + ui.Clr.Reset)
+		keys := slices.Sorted(maps.Keys(fileCfg.wakafetch))
+		for _, key := range keys {
+			fmt.Printf("  %s = %s\n", key, fileCfg.wakafetch[key])
+		}
+	}
+}
+
+// describeKeySource says where a profile's key comes from, without running vault commands or printing the key
+func describeKeySource(p profileConfig) string {
+	if p.name == "default" {
+		for _, env := range []string{"WAKAFETCH_API_KEY", "WAKATIME_API_KEY"} {
+			if os.Getenv(env) != "" {
+				return "(key from $" + env + ")"
+			}
+		}
+	}
+	switch {
+	case len(p.apiKey) >= 16:
+		return "(key ..." + p.apiKey[len(p.apiKey)-4:] + ")"
+	case p.apiKey != "":
+		return "(key set)"
+	case p.apiKeyVaultCmd != "":
+		return "(key from api_key_vault_cmd)"
+	}
+	return "(no api key)"
+}
+
+func runCache(config Config) {
+	action := "info"
+	if len(config.args) > 0 {
+		action = config.args[0]
+	}
+
+	dir, err := cacheDir()
+	if err != nil {
+		ui.Errorln("Failed to find cache directory: %s", err.Error())
+	}
+	entries, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+
+	switch action {
+	case "path":
+		fmt.Println(dir)
+	case "clear":
+		for _, entry := range entries {
+			if err := os.Remove(entry); err != nil {
+				ui.Errorln("Failed to remove %s: %s", entry, err.Error())
+			}
+		}
+		fmt.Printf("Removed %d cached responses\n", len(entries))
+	case "info":
+		var size int64
+		var newest time.Time
+		for _, entry := range entries {
+			if info, err := os.Stat(entry); err == nil {
+				size += info.Size()
+				if info.ModTime().After(newest) {
+					newest = info.ModTime()
+				}
+			}
+		}
+		fmt.Printf("%s%-10s%s%s\n", ui.Clr.BoldBlue, "Path", ui.Clr.Reset, dir)
+		fmt.Printf("%s%-10s%s%d\n", ui.Clr.BoldBlue, "Entries", ui.Clr.Reset, len(entries))
+		fmt.Printf("%s%-10s%s%.1f KiB\n", ui.Clr.BoldBlue, "Size", ui.Clr.Reset, float64(size)/1024)
+		if !newest.IsZero() {
+			fmt.Printf("%s%-10s%s%s\n", ui.Clr.BoldBlue, "Updated", ui.Clr.Reset, newest.Format("Jan 2 15:04"))
+		}
+	default:
+		ui.Errorln("Unknown cache action: '%s', must be one of %s", action, strings.Join([]string{"info", "path", "clear"}, ", "))
+	}
+}
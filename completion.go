@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+// the scripts are generated from each command's registered flags, so they never go out of date.
+// Dynamic values (profiles, projects from the cache) are looked up at completion time with the hidden `wakafetch __complete <kind>`
+
+// valueCompletion is what completes after a flag that takes a value
+type valueCompletion struct {
+	words   []string
+	dynamic string // kind passed to `wakafetch __complete`
+	files   bool
+}
+
+var flagValueCompletions = map[string]valueCompletion{
+	"range":        {words: []string{"today", "7d", "30d", "6m", "1y", "all"}},
+	"format":       {words: []string{"text", "json", "csv", "tsv", "svg", "markdown"}},
+	"svg-theme":    {words: ui.SVGThemeNames()},
+	"theme":        {words: ui.HeatmapThemeNames()},
+	"project":      {dynamic: "projects"},
+	"profile":      {dynamic: "profiles"},
+	"config":       {files: true},
+	"api-key-file": {files: true},
+	"output":       {files: true},
+	"html":         {files: true},
+}
+
+// dynamicCompletions print one candidate per line, errors just mean no candidates
+var dynamicCompletions = map[string]func() []string{
+	"profiles": func() []string {
+		fileCfg, err := parseConfig("")
+		if err != nil {
+			return nil
+		}
+		return profileNames(fileCfg)
+	},
+	"projects": cachedProjects,
+}
+
+type completionCommand struct {
+	command
+	flags []flagInfo
+}
+
+func completionCommands() []completionCommand {
+	var cmds []completionCommand
+	for _, cmd := range commands {
+		if cmd.hidden {
+			continue
+		}
+		c := newConfig(cmd)
+		cmd.register(&c)
+		cmds = append(cmds, completionCommand{cmd, c.registeredFlags})
+	}
+	return cmds
+}
+
+func runCompletion(config Config) {
+	if len(config.args) == 0 {
+		ui.Errorln("Missing shell, must be one of bash, zsh, fish")
+	}
+	switch config.args[0] {
+	case "bash":
+		fmt.Print(bashCompletion(completionCommands()))
+	case "zsh":
+		fmt.Print(zshCompletion(completionCommands()))
+	case "fish":
+		fmt.Print(fishCompletion(completionCommands()))
+	default:
+		ui.Errorln("Unsupported shell: '%s', must be one of bash, zsh, fish", config.args[0])
+	}
+}
+
+func runComplete(config Config) {
+	if len(config.args) == 0 {
+		return
+	}
+	if complete, ok := dynamicCompletions[config.args[0]]; ok {
+		for _, candidate := range complete() {
+			fmt.Println(candidate)
+		}
+	}
+	os.Exit(0)
+}
+
+func flagNames(f flagInfo) []string {
+	names := []string{"--" + f.longName}
+	if f.shortName != "" {
+		names = append(names, "-"+f.shortName)
+	}
+	return names
+}
+
+// valueFlags returns every flag taking a value, across all commands, as `--long|-s` patterns grouped by completion
+func valueFlags(cmds []completionCommand) (words map[string][]string, dynamic map[string]string, files, plain []string) {
+	words, dynamic = make(map[string][]string), make(map[string]string)
+	seen := make(map[string]bool)
+	for _, cmd := range cmds {
+		for _, f := range cmd.flags {
+			if f.flagType == "" || seen[f.longName] {
+				continue
+			}
+			seen[f.longName] = true
+			names := strings.Join(flagNames(f), "|")
+			vc := flagValueCompletions[f.longName]
+			switch {
+			case vc.words != nil:
+				words[names] = vc.words
+			case vc.dynamic != "":
+				dynamic[names] = vc.dynamic
+			case vc.files:
+				files = append(files, names)
+			default:
+				plain = append(plain, names)
+			}
+		}
+	}
+	return words, dynamic, files, plain
+}
+
+func subcommandNames(cmds []completionCommand) []string {
+	var names []string
+	for _, cmd := range cmds[1:] {
+		names = append(names, cmd.name)
+	}
+	return names
+}
+
+func bashCompletion(cmds []completionCommand) string {
+	var b strings.Builder
+	words, dynamic, files, plain := valueFlags(cmds)
+
+	b.WriteString("# bash completion for wakafetch, generated by `wakafetch completion bash`\n")
+	b.WriteString("_wakafetch() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    case \"$prev\" in\n")
+	for _, names := range slices.Sorted(maps.Keys(words)) {
+		fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n", names, strings.Join(words[names], " "))
+	}
+	for _, names := range slices.Sorted(maps.Keys(dynamic)) {
+		fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W \"$(wakafetch __complete %s 2>/dev/null)\" -- \"$cur\")); return ;;\n", names, dynamic[names])
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(files, "|"))
+	}
+	if len(plain) > 0 {
+		fmt.Fprintf(&b, "        %s) return ;;\n", strings.Join(plain, "|"))
+	}
+	b.WriteString("    esac\n\n")
+
+	b.WriteString("    local cmd=\"\" word\n")
+	b.WriteString("    for word in \"${COMP_WORDS[@]:1:COMP_CWORD-1}\"; do\n")
+	fmt.Fprintf(&b, "        case \"$word\" in %s) cmd=\"$word\"; break ;; esac\n", strings.Join(subcommandNames(cmds), "|"))
+	b.WriteString("    done\n\n")
+
+	b.WriteString("    local opts\n")
+	b.WriteString("    case \"$cmd\" in\n")
+	for _, cmd := range cmds {
+		var opts []string
+		if cmd.name == "" {
+			opts = append(opts, subcommandNames(cmds)...)
+		}
+		opts = append(opts, cmd.argValues...)
+		for _, f := range cmd.flags {
+			opts = append(opts, "--"+f.longName)
+		}
+		fmt.Fprintf(&b, "        %q) opts=\"%s\" ;;\n", cmd.name, strings.Join(opts, " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$opts\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -F _wakafetch wakafetch\n")
+	return b.String()
+}
+
+func zshCompletion(cmds []completionCommand) string {
+	var b strings.Builder
+	words, dynamic, files, plain := valueFlags(cmds)
+
+	b.WriteString("#compdef wakafetch\n")
+	b.WriteString("# zsh completion for wakafetch, generated by `wakafetch completion zsh`\n")
+	b.WriteString("_wakafetch() {\n")
+	b.WriteString("    local prev=${words[CURRENT-1]}\n")
+	b.WriteString("    case $prev in\n")
+	for _, names := range slices.Sorted(maps.Keys(words)) {
+		fmt.Fprintf(&b, "        %s) compadd -- %s; return ;;\n", names, strings.Join(words[names], " "))
+	}
+	for _, names := range slices.Sorted(maps.Keys(dynamic)) {
+		fmt.Fprintf(&b, "        %s) compadd -- ${(f)\"$(wakafetch __complete %s 2>/dev/null)\"}; return ;;\n", names, dynamic[names])
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(&b, "        %s) _files; return ;;\n", strings.Join(files, "|"))
+	}
+	if len(plain) > 0 {
+		fmt.Fprintf(&b, "        %s) return ;;\n", strings.Join(plain, "|"))
+	}
+	b.WriteString("    esac\n\n")
+
+	b.WriteString("    local cmd=\"\" word\n")
+	b.WriteString("    for word in ${words[2,CURRENT-1]}; do\n")
+	fmt.Fprintf(&b, "        case $word in %s) cmd=$word; break ;; esac\n", strings.Join(subcommandNames(cmds), "|"))
+	b.WriteString("    done\n\n")
+
+	b.WriteString("    local -a opts args\n")
+	b.WriteString("    case $cmd in\n")
+	for _, cmd := range cmds {
+		var opts, args []string
+		if cmd.name == "" {
+			for _, sub := range cmds[1:] {
+				args = append(args, zshQuote(sub.name+":"+sub.description))
+			}
+		}
+		for _, val := range cmd.argValues {
+			args = append(args, zshQuote(val))
+		}
+		for _, f := range cmd.flags {
+			opts = append(opts, zshQuote("--"+f.longName+":"+f.description))
+		}
+		fmt.Fprintf(&b, "        %q)\n", cmd.name)
+		fmt.Fprintf(&b, "            args=(%s)\n", strings.Join(args, " "))
+		fmt.Fprintf(&b, "            opts=(%s) ;;\n", strings.Join(opts, " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ $PREFIX == -* ]]; then\n")
+	b.WriteString("        _describe 'option' opts\n")
+	b.WriteString("    else\n")
+	b.WriteString("        _describe 'command' args\n")
+	b.WriteString("    fi\n")
+	b.WriteString("}\n")
+	b.WriteString("compdef _wakafetch wakafetch\n")
+	return b.String()
+}
+
+func fishCompletion(cmds []completionCommand) string {
+	var b strings.Builder
+	subcommands := strings.Join(subcommandNames(cmds), " ")
+
+	b.WriteString("# fish completion for wakafetch, generated by `wakafetch completion fish`\n")
+	b.WriteString("complete -c wakafetch -f\n")
+	for _, cmd := range cmds[1:] {
+		fmt.Fprintf(&b, "complete -c wakafetch -n '__fish_use_subcommand' -a %s -d %s\n", cmd.name, fishQuote(cmd.description))
+	}
+
+	for _, cmd := range cmds {
+		condition := "not __fish_seen_subcommand_from " + subcommands
+		if cmd.name != "" {
+			condition = "__fish_seen_subcommand_from " + cmd.name
+		}
+		if len(cmd.argValues) > 0 {
+			fmt.Fprintf(&b, "complete -c wakafetch -n '%s' -a '%s'\n", condition, strings.Join(cmd.argValues, " "))
+		}
+		for _, f := range cmd.flags {
+			line := fmt.Sprintf("complete -c wakafetch -n '%s' -l %s", condition, f.longName)
+			if f.shortName != "" {
+				line += " -s " + f.shortName
+			}
+			if f.flagType != "" {
+				vc := flagValueCompletions[f.longName]
+				switch {
+				case vc.words != nil:
+					line += " -x -a '" + strings.Join(vc.words, " ") + "'"
+				case vc.dynamic != "":
+					line += " -x -a '(wakafetch __complete " + vc.dynamic + " 2>/dev/null)'"
+				case vc.files:
+					line += " -r -F"
+				default:
+					line += " -x"
+				}
+			}
+			line += " -d " + fishQuote(f.description)
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
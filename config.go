@@ -1,69 +1,160 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sahaj-b/wakafetch/ui"
 )
 
-func parseConfig() (string, string, error) {
-	configPath := getConfigPath()
+// profileConfig is one account, [settings] is the default profile and [wakafetch.<name>] sections are named ones
+type profileConfig struct {
+	name           string
+	apiURL         string
+	apiKey         string
+	apiKeyVaultCmd string
+}
 
-	file, err := os.Open(configPath)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to open config file: %w", err)
-	}
-	defer file.Close()
+type fileConfig struct {
+	defaultProfile profileConfig
+	profiles       []profileConfig // sorted by name
+	proxy          string
+	noSSLVerify    bool
+	sslCertsFile   string
+	timeout        time.Duration
+	wakafetch      map[string]string // [wakafetch] section, for settings wakatime-cli doesn't know about
+	goals          []goal            // [goals] section
+}
 
-	var apiURL, apiKey string
-	scanner := bufio.NewScanner(file)
+// parseConfig reads the wakatime config, with wakafetch's own config (if any) merged on top
+func parseConfig(configFlag string) (fileConfig, error) {
+	var cfg fileConfig
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+	ini, err := parseINI(getConfigPath(configFlag))
+	if err != nil {
+		ini = iniFile{}
+		// the wakafetch config alone is enough, as long as it has the api settings
+		if configFlag != "" || !errors.Is(err, fs.ErrNotExist) || !fileExists(getWakafetchConfigPath()) {
+			return cfg, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
 
-		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
-			continue
+	if path := getWakafetchConfigPath(); fileExists(path) {
+		wakafetchIni, err := parseINI(path)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read config file: %w", err)
 		}
+		ini.merge(wakafetchIni)
+	}
 
-		if strings.HasPrefix(line, "api_url") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				apiURL = strings.TrimSpace(parts[1])
-			}
-		} else if strings.HasPrefix(line, "api_key") {
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				apiKey = strings.TrimSpace(parts[1])
-			}
+	cfg.defaultProfile = readProfile(ini, "settings", "default")
+	for section := range ini {
+		if name, found := strings.CutPrefix(section, "wakafetch."); found && name != "" {
+			cfg.profiles = append(cfg.profiles, readProfile(ini, section, name))
 		}
+	}
+	slices.SortFunc(cfg.profiles, func(a, b profileConfig) int { return strings.Compare(a.name, b.name) })
+	cfg.proxy = ini.get("settings", "proxy")
+	cfg.sslCertsFile = expandHome(ini.get("settings", "ssl_certs_file"))
+	cfg.wakafetch = ini["wakafetch"]
+	if cfg.goals, err = parseGoals(ini["goals"]); err != nil {
+		return cfg, err
+	}
 
-		if apiURL != "" && apiKey != "" {
-			break
+	if val := ini.get("settings", "no_ssl_verify"); val != "" {
+		cfg.noSSLVerify, err = strconv.ParseBool(val)
+		if err != nil {
+			return cfg, fmt.Errorf("Invalid no_ssl_verify in config: '%s', must be true or false", val)
 		}
 	}
 
-	if apiURL == "" {
-		return "", "", fmt.Errorf("api_url not found in config")
+	// wakatime-cli's timeout is in seconds
+	if val := ini.get("settings", "timeout"); val != "" {
+		secs, err := strconv.Atoi(val)
+		if err != nil || secs <= 0 {
+			return cfg, fmt.Errorf("Invalid timeout in config: '%s', must be a positive number of seconds", val)
+		}
+		cfg.timeout = time.Duration(secs) * time.Second
 	}
 
-	if apiKey == "" {
-		return "", "", fmt.Errorf("api_key not found in config")
+	return cfg, nil
+}
+
+func readProfile(ini iniFile, section, name string) profileConfig {
+	return profileConfig{
+		name:           name,
+		apiURL:         normalizeAPIURL(ini.get(section, "api_url")),
+		apiKey:         ini.get(section, "api_key"),
+		apiKeyVaultCmd: ini.get(section, "api_key_vault_cmd"),
 	}
+}
 
+func normalizeAPIURL(apiURL string) string {
 	apiURL = strings.TrimSuffix(apiURL, "/")
 
 	if apiURL == "https://wakapi.dev/api" {
 		apiURL = "https://wakapi.dev/api/compat/wakatime"
 	}
+	return apiURL
+}
+
+// runVaultCmd runs api_key_vault_cmd (e.g. `pass show wakatime`) and returns its trimmed stdout
+func runVaultCmd(command string) (string, error) {
+	const timeout = 10 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// let pinentry/1Password prompts reach the terminal
+	cmd.Stdin = os.Stdin
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("api_key_vault_cmd timed out after %s", timeout)
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("api_key_vault_cmd failed: %w", err)
+		}
+		return "", fmt.Errorf("api_key_vault_cmd failed: %w: %s", err, msg)
+	}
 
-	return apiURL, apiKey, nil
+	key := strings.TrimSpace(stdout.String())
+	if key == "" {
+		return "", fmt.Errorf("api_key_vault_cmd printed nothing to stdout")
+	}
+	return key, nil
 }
 
-func getConfigPath() string {
+// getConfigPath finds .wakatime.cfg: --config, then $WAKATIME_HOME, then the home dir
+func getConfigPath(configFlag string) string {
+	if configFlag != "" {
+		return expandHome(configFlag)
+	}
+	if wakatimeHome := os.Getenv("WAKATIME_HOME"); wakatimeHome != "" {
+		return filepath.Join(expandHome(wakatimeHome), ".wakatime.cfg")
+	}
+
 	var configFile string
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -73,3 +164,20 @@ func getConfigPath() string {
 
 	return configFile
 }
+
+// getWakafetchConfigPath is wakafetch's own config, $XDG_CONFIG_HOME/wakafetch/wakafetch.cfg
+func getWakafetchConfigPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "wakafetch", "wakafetch.cfg")
+}
+
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// dateRange is an inclusive range of calendar days for the /summaries endpoint
+type dateRange struct {
+	start  time.Time
+	end    time.Time
+	label  string
+	custom bool // from --from/--to, --month or --week
+}
+
+// days counts calendar days, on UTC midnights so a DST change in the range doesn't make a 23 hour day
+func (r dateRange) days() int {
+	utcDay := func(t time.Time) time.Time { return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC) }
+	return int(utcDay(r.end).Sub(utcDay(r.start)).Hours()/24) + 1
+}
+
+// previous is the range of the same length right before r, for --compare
+func (r dateRange) previous() dateRange {
+	days := r.days()
+	return dateRange{start: r.start.AddDate(0, 0, -days), end: r.start.AddDate(0, 0, -1)}
+}
+
+func lastNDays(days int) dateRange {
+	today := truncateToDay(time.Now())
+	return dateRange{start: today.AddDate(0, 0, -days+1), end: today}
+}
+
+func hasCustomRange(config Config) bool {
+	return *config.fromFlag != "" || *config.toFlag != "" || *config.monthFlag != "" || *config.weekFlag != ""
+}
+
+func parseCustomRange(config Config) (dateRange, error) {
+	var r dateRange
+	var err error
+
+	used := 0
+	// --from and --to share a slot, --to alone with --month shouldn't be silently ignored
+	for _, f := range []string{*config.fromFlag + *config.toFlag, *config.monthFlag, *config.weekFlag} {
+		if f != "" {
+			used++
+		}
+	}
+	if used > 1 {
+		return r, fmt.Errorf("Only one of --from/--to, --month and --week can be used at a time")
+	}
+	if *config.daysFlag != 0 {
+		return r, fmt.Errorf("--days can't be combined with --from/--to, --month or --week")
+	}
+
+	switch {
+	case *config.monthFlag != "":
+		r, err = parseMonth(*config.monthFlag)
+	case *config.weekFlag != "":
+		r, err = parseISOWeek(*config.weekFlag)
+	case *config.fromFlag != "":
+		r, err = parseFromTo(*config.fromFlag, *config.toFlag)
+	default:
+		return r, fmt.Errorf("--to requires --from")
+	}
+	if err != nil {
+		return r, err
+	}
+
+	today := truncateToDay(time.Now())
+	if r.start.After(today) {
+		return r, fmt.Errorf("Date range starts in the future (%s)", r.start.Format(dateLayout))
+	}
+	if r.end.After(today) {
+		r.end = today
+	}
+	r.custom = true
+	return r, nil
+}
+
+func parseFromTo(from, to string) (dateRange, error) {
+	start, err := time.ParseInLocation(dateLayout, from, time.Local)
+	if err != nil {
+		return dateRange{}, fmt.Errorf("Invalid --from date: '%s', expected YYYY-MM-DD", from)
+	}
+	end := truncateToDay(time.Now())
+	if to != "" {
+		end, err = time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return dateRange{}, fmt.Errorf("Invalid --to date: '%s', expected YYYY-MM-DD", to)
+		}
+	}
+	if end.Before(start) {
+		return dateRange{}, fmt.Errorf("--to (%s) is before --from (%s)", to, from)
+	}
+	return dateRange{start: start, end: end, label: "Custom range"}, nil
+}
+
+// parseMonth parses YYYY-MM
+func parseMonth(month string) (dateRange, error) {
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return dateRange{}, fmt.Errorf("Invalid --month: '%s', expected YYYY-MM", month)
+	}
+	end := start.AddDate(0, 1, -1)
+	return dateRange{start: start, end: end, label: start.Format("January 2006")}, nil
+}
+
+// parseISOWeek parses YYYY-Www (ISO 8601 week, Monday to Sunday)
+func parseISOWeek(week string) (dateRange, error) {
+	invalid := fmt.Errorf("Invalid --week: '%s', expected YYYY-Www (e.g. 2026-W37)", week)
+	yearStr, weekStr, found := strings.Cut(strings.ToUpper(week), "-W")
+	if !found {
+		return dateRange{}, invalid
+	}
+	year, err1 := strconv.Atoi(yearStr)
+	weekNum, err2 := strconv.Atoi(weekStr)
+	if err1 != nil || err2 != nil || weekNum < 1 || weekNum > 53 {
+		return dateRange{}, invalid
+	}
+
+	// week 1 is the week containing January 4th
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+	week1Monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	start := week1Monday.AddDate(0, 0, (weekNum-1)*7)
+	if y, w := start.ISOWeek(); y != year || w != weekNum {
+		return dateRange{}, fmt.Errorf("%d has no week %d", year, weekNum)
+	}
+	end := start.AddDate(0, 0, 6)
+	return dateRange{start: start, end: end, label: fmt.Sprintf("Week %d, %d", weekNum, year)}, nil
+}
+
+// weekStart is the monday of t's week
+func weekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -4,21 +4,59 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/sahaj-b/wakafetch/ui"
 )
 
 type Config struct {
-	rangeFlag   *string
-	apiKeyFlag  *string
-	fullFlag    *bool
-	daysFlag    *int
-	dailyFlag   *bool
-	heatmapFlag *bool
-	noColorFlag *bool
-	jsonFlag    *bool
-	helpFlag    *bool
+	rangeFlag       *string
+	apiKeyFlag      *string
+	apiKeyFileFlag  *string
+	configFlag      *string
+	profileFlag     *string
+	allProfilesFlag *bool
+	fullFlag        *bool
+	daysFlag        *int
+	fromFlag        *string
+	toFlag          *string
+	monthFlag       *string
+	weekFlag        *string
+	projectFlag     *string
+	compareFlag     *bool
+	hoursFlag       *bool
+	dateFlag        *string
+	themeFlag       *string
+	heatLevelsFlag  *string
+	minActiveFlag   *time.Duration
+	dailyFlag       *bool
+	heatmapFlag     *bool
+	noColorFlag     *bool
+	jsonFlag        *bool
+	formatFlag      *string
+	svgThemeFlag    *string
+	watchFlag       *time.Duration
+	outputFlag      *string
+	htmlFlag        *string
+	metricsFlag     *string
+	intervalFlag    *time.Duration
+	rangesFlag      *string
+	historyFlag     *int
+	dailyDaysFlag   *int
+	offlineFlag     *bool
+	refreshFlag     *bool
+	cacheTTL        *time.Duration
+	retriesFlag     *int
+	retryMaxWait    *time.Duration
+	helpFlag        *bool
+
+	command         command
+	args            []string // positional args after the flags
+	fs              *flag.FlagSet
+	registeredFlags []flagInfo
+	setFlags        map[string]bool // long names of the flags given on the command line
 }
 
 type flagInfo struct {
@@ -29,75 +67,333 @@ type flagInfo struct {
 	flagType    string
 }
 
-func parseFlags() Config {
-	config := Config{}
-	registeredFlags = nil
+// newConfig points every flag at a zero value, so commands that don't register a flag can still read it
+func newConfig(cmd command) Config {
+	name := "wakafetch"
+	if cmd.name != "" {
+		name += " " + cmd.name
+	}
+	return Config{
+		rangeFlag:       new(string),
+		apiKeyFlag:      new(string),
+		apiKeyFileFlag:  new(string),
+		configFlag:      new(string),
+		profileFlag:     new(string),
+		allProfilesFlag: new(bool),
+		fullFlag:        new(bool),
+		daysFlag:        new(int),
+		fromFlag:        new(string),
+		toFlag:          new(string),
+		monthFlag:       new(string),
+		weekFlag:        new(string),
+		projectFlag:     new(string),
+		compareFlag:     new(bool),
+		hoursFlag:       new(bool),
+		dateFlag:        new(string),
+		themeFlag:       new(string),
+		heatLevelsFlag:  new(string),
+		minActiveFlag:   new(time.Duration),
+		dailyFlag:       new(bool),
+		heatmapFlag:     new(bool),
+		noColorFlag:     new(bool),
+		jsonFlag:        new(bool),
+		formatFlag:      new(string),
+		svgThemeFlag:    new(string),
+		watchFlag:       new(time.Duration),
+		outputFlag:      new(string),
+		htmlFlag:        new(string),
+		metricsFlag:     new(string),
+		intervalFlag:    new(time.Duration),
+		rangesFlag:      new(string),
+		historyFlag:     new(int),
+		dailyDaysFlag:   new(int),
+		offlineFlag:     new(bool),
+		refreshFlag:     new(bool),
+		cacheTTL:        new(time.Duration),
+		retriesFlag:     new(int),
+		retryMaxWait:    new(time.Duration),
+		helpFlag:        new(bool),
+		command:         cmd,
+		fs:              flag.NewFlagSet(name, flag.ExitOnError),
+		setFlags:        make(map[string]bool),
+	}
+}
+
+func parseFlags(args []string) Config {
+	cmd := commands[0] // bare `wakafetch`
+	if len(args) > 0 {
+		if found, ok := findCommand(args[0]); ok {
+			cmd = found
+			args = args[1:]
+		}
+	}
+
+	config := newConfig(cmd)
+	cmd.register(&config)
 
-	config.rangeFlag = config.stringFlag("range", "r", "7d", "Range of data to fetch (today/7d/30d/6m/1y/all) (default: 7d)")
-	config.daysFlag = config.intFlag("days", "d", 0, "Number of days to fetch data for (overrides --range)")
-	config.fullFlag = config.boolFlag("full", "f", false, "Display full statistics")
-	config.dailyFlag = config.boolFlag("daily", "D", false, "Display daily breakdown")
-	config.heatmapFlag = config.boolFlag("heatmap", "H", false, "Display heatmap of daily activity")
-	config.apiKeyFlag = config.stringFlag("api-key", "k", "", "Your WakaTime/Wakapi API key (overrides config)")
-	config.noColorFlag = config.boolFlag("no-colors", "n", false, "Disable colored output")
-	config.jsonFlag = config.boolFlag("json", "j", false, "Output data in JSON format")
-	config.helpFlag = config.boolFlag("help", "h", false, "Display help information")
+	config.fs.Usage = func() { showCustomHelp(config) }
+	config.fs.Parse(args)
+	config.args = config.fs.Args()
 
-	flag.Usage = showCustomHelp
-	flag.Parse()
+	config.fs.Visit(func(f *flag.Flag) {
+		for _, info := range config.registeredFlags {
+			if info.shortName == f.Name || info.longName == f.Name {
+				config.setFlags[info.longName] = true
+			}
+		}
+	})
 
 	if *config.noColorFlag || !colorsShouldBeEnabled() {
 		ui.DisableColors()
 	}
 
 	if *config.helpFlag {
-		showCustomHelp()
+		showCustomHelp(config)
 	}
 
+	return config
+}
+
+// flag groups shared between commands
+
+func (c *Config) rangeFlags() {
+	c.rangeFlag = c.stringFlag("range", "r", "7d", "Range of data to fetch (today/7d/30d/6m/1y/all) (default: 7d)")
+	c.daysFlag = c.intFlag("days", "d", 0, "Number of days to fetch data for (overrides --range)")
+	c.fromFlag = c.stringFlag("from", "", "", "Start date YYYY-MM-DD (overrides --range)")
+	c.toFlag = c.stringFlag("to", "", "", "End date YYYY-MM-DD, used with --from (default: today)")
+	c.monthFlag = c.stringFlag("month", "m", "", "Calendar month YYYY-MM (overrides --range)")
+	c.weekFlag = c.stringFlag("week", "w", "", "ISO week YYYY-Www, e.g. 2026-W37 (overrides --range)")
+	c.projectFlag = c.stringFlag("project", "P", "", "Only count time spent on this project")
+}
+
+func (c *Config) accountFlags() {
+	c.apiKeyFlag = c.stringFlag("api-key", "k", "", "Your WakaTime/Wakapi API key (overrides config)")
+	c.apiKeyFileFlag = c.stringFlag("api-key-file", "", "", "Read the API key from a file (overrides config)")
+	c.configFlag = c.stringFlag("config", "c", "", "Path to .wakatime.cfg (default: $WAKATIME_HOME/.wakatime.cfg or ~/.wakatime.cfg)")
+	c.profileFlag = c.stringFlag("profile", "p", "", "Account from a [wakafetch.<name>] config section (default: [settings])")
+	c.allProfilesFlag = c.boolFlag("all-profiles", "a", false, "Combine stats from every configured account")
+}
+
+func (c *Config) fetchFlags() {
+	c.offlineFlag = c.boolFlag("offline", "o", false, "Only use cached data, never hit the network")
+	c.refreshFlag = c.boolFlag("refresh", "R", false, "Ignore cached data and fetch fresh data")
+	c.cacheTTL = c.durationFlag("cache-ttl", "", 5*time.Minute, "How long cached data is considered fresh (default: 5m)")
+	c.retriesFlag = c.intFlag("retries", "", 3, "Retries for failed or pending requests (default: 3)")
+	c.retryMaxWait = c.durationFlag("retry-max-wait", "", 30*time.Second, "Longest wait between retries (default: 30s)")
+}
+
+func (c *Config) outputFlags() {
+	c.jsonFlag = c.boolFlag("json", "j", false, "Output data in JSON format (same as --format json)")
+	c.formatFlag = c.stringFlag("format", "", "text", "Output format (text/json/csv/tsv/svg/markdown) (default: text)")
+	c.svgThemeFlag = c.stringFlag("svg-theme", "", "dark", "Colors for --format svg ("+strings.Join(ui.SVGThemeNames(), "/")+") (default: dark)")
+}
+
+func (c *Config) heatmapFlags() {
+	c.themeFlag = c.stringFlag("theme", "", "green", "Heatmap colors ("+strings.Join(ui.HeatmapThemeNames(), "/")+") (default: green)")
+	c.heatLevelsFlag = c.stringFlag("heatmap-levels", "", "quantile", "Least time per heatmap shade like 30m,1h,2h,4h, or quantile (default: quantile)")
+}
+
+func (c *Config) colorFlag() {
+	c.noColorFlag = c.boolFlag("no-colors", "n", false, "Disable colored output")
+}
+
+func (c *Config) helpFlags() {
+	c.helpFlag = c.boolFlag("help", "h", false, "Display help information")
+}
+
+// applyConfigDefaults uses the [wakafetch] section of the config as defaults for flags not given explicitly,
+// e.g. `range = 30d` or `full = true`. Keys are flag names, with `_` or `-`
+func applyConfigDefaults(config Config, values map[string]string) {
+	// a range given on the command line replaces the configured one entirely, `days = 14` shouldn't override `-r 30d`
+	rangeGiven := false
+	for _, long := range rangeFlagNames {
+		rangeGiven = rangeGiven || config.isSet(long)
+	}
+
+	for key, val := range values {
+		long := strings.ReplaceAll(key, "_", "-")
+		if !isKnownFlag(long) || long == "help" || long == "config" {
+			ui.Warnln("Unknown setting in [wakafetch] config section: '%s'", key)
+			continue
+		}
+		// settings for other commands, like `full = true` while running `wakafetch cache`
+		if !config.hasFlag(long) {
+			continue
+		}
+		if config.isSet(long) || (rangeGiven && slices.Contains(rangeFlagNames, long)) {
+			continue
+		}
+		if err := config.fs.Set(long, val); err != nil {
+			ui.Errorln("Invalid %s in config: '%s'", key, val)
+		}
+	}
+
+	if *config.noColorFlag {
+		ui.DisableColors()
+	}
+}
+
+var rangeFlagNames = []string{"range", "days", "from", "to", "month", "week"}
+
+func (c Config) hasFlag(long string) bool {
+	for _, f := range c.registeredFlags {
+		if f.longName == long {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnownFlag reports whether any command has the flag
+func isKnownFlag(long string) bool {
+	for _, cmd := range commands {
+		c := newConfig(cmd)
+		cmd.register(&c)
+		if c.hasFlag(long) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateFlags runs after config defaults are applied, so it checks those too
+func validateFlags(config Config) {
 	if *config.daysFlag < 0 {
 		ui.Errorln("Invalid value for --days: must be a positive integer")
 	}
 
-	return config
+	if *config.retriesFlag < 0 {
+		ui.Errorln("Invalid value for --retries: must be a non-negative integer")
+	}
+	if *config.retryMaxWait < 0 {
+		ui.Errorln("Invalid value for --retry-max-wait: must not be negative")
+	}
+	if *config.cacheTTL < 0 {
+		ui.Errorln("Invalid value for --cache-ttl: must not be negative")
+	}
+
+	if *config.minActiveFlag < 0 {
+		ui.Errorln("Invalid value for --min-active: must not be negative")
+	}
+
+	if *config.offlineFlag && *config.refreshFlag {
+		ui.Errorln("--offline and --refresh can't be used together")
+	}
+
+	if *config.jsonFlag {
+		if config.isSet("format") && *config.formatFlag != "json" {
+			ui.Errorln("--json and --format %s can't be used together", *config.formatFlag)
+		}
+		*config.formatFlag = "json"
+	}
+	if config.hasFlag("format") && !isValidFormat(config, *config.formatFlag) {
+		ui.Errorln("Invalid format: '%s', must be one of %s", *config.formatFlag, strings.Join(commandFormats(config), ", "))
+	}
+	if *config.compareFlag {
+		if *config.dailyFlag || *config.heatmapFlag {
+			ui.Errorln("--compare can't be used with --daily or --heatmap")
+		}
+		if f := *config.formatFlag; f != "text" && f != "markdown" && f != "svg" {
+			ui.Errorln("--compare only works with the text, markdown and svg formats")
+		}
+	}
+	if *config.hoursFlag {
+		if *config.dailyFlag || *config.heatmapFlag {
+			ui.Errorln("--hours can't be used with --daily or --heatmap")
+		}
+		if !*config.fullFlag && *config.projectFlag == "" {
+			ui.Errorln("--hours only works with --full or --project")
+		}
+		if f := *config.formatFlag; f != "text" && f != "markdown" && f != "svg" {
+			ui.Errorln("--hours only works with the text, markdown and svg formats")
+		}
+	}
+	if _, ok := ui.HeatmapThemes[*config.themeFlag]; config.hasFlag("theme") && !ok {
+		ui.Errorln("Invalid theme: '%s', must be one of %s", *config.themeFlag, strings.Join(ui.HeatmapThemeNames(), ", "))
+	}
+	if _, ok := ui.SVGThemes[*config.svgThemeFlag]; config.hasFlag("svg-theme") && !ok {
+		ui.Errorln("Invalid SVG theme: '%s', must be one of %s", *config.svgThemeFlag, strings.Join(ui.SVGThemeNames(), ", "))
+	}
 }
 
-var registeredFlags []flagInfo
+// isSet reports whether a flag was given explicitly, so config file values don't override it
+func (c Config) isSet(long string) bool {
+	return c.setFlags[long]
+}
 
 func (c *Config) stringFlag(long, short, def, desc string) *string {
-	registeredFlags = append(registeredFlags, flagInfo{long, short, def, desc, "string"})
-	val := flag.String(long, def, "")
-	flag.StringVar(val, short, def, "")
+	c.registeredFlags = append(c.registeredFlags, flagInfo{long, short, def, desc, "string"})
+	val := c.fs.String(long, def, "")
+	if short != "" {
+		c.fs.StringVar(val, short, def, "")
+	}
 	return val
 }
 
 func (c *Config) boolFlag(long, short string, def bool, desc string) *bool {
-	registeredFlags = append(registeredFlags, flagInfo{long, short, def, desc, ""})
-	val := flag.Bool(long, def, "")
-	flag.BoolVar(val, short, def, "")
+	c.registeredFlags = append(c.registeredFlags, flagInfo{long, short, def, desc, ""})
+	val := c.fs.Bool(long, def, "")
+	if short != "" {
+		c.fs.BoolVar(val, short, def, "")
+	}
 	return val
 }
 
 func (c *Config) intFlag(long, short string, def int, desc string) *int {
-	registeredFlags = append(registeredFlags, flagInfo{long, short, def, desc, "int"})
-	val := flag.Int(long, def, "")
-	flag.IntVar(val, short, def, "")
+	c.registeredFlags = append(c.registeredFlags, flagInfo{long, short, def, desc, "int"})
+	val := c.fs.Int(long, def, "")
+	if short != "" {
+		c.fs.IntVar(val, short, def, "")
+	}
 	return val
 }
 
-func showCustomHelp() {
-	fmt.Println(ui.Clr.Bold + "Usage:" + ui.Clr.Reset + " wakafetch [options]")
+func (c *Config) durationFlag(long, short string, def time.Duration, desc string) *time.Duration {
+	c.registeredFlags = append(c.registeredFlags, flagInfo{long, short, def, desc, "duration"})
+	val := c.fs.Duration(long, def, "")
+	if short != "" {
+		c.fs.DurationVar(val, short, def, "")
+	}
+	return val
+}
+
+func showCustomHelp(config Config) {
+	cmd := config.command
+	if cmd.name == "" {
+		fmt.Println(ui.Clr.Bold + "Usage:" + ui.Clr.Reset + " wakafetch [command] [options]")
+		fmt.Println(ui.Clr.Bold + "Commands:" + ui.Clr.Reset)
+		maxWidth := 0
+		for _, c := range commands[1:] {
+			maxWidth = max(maxWidth, len(c.name))
+		}
+		for _, c := range commands[1:] {
+			if c.hidden {
+				continue
+			}
+			padding := strings.Repeat(" ", maxWidth-len(c.name)+2)
+			fmt.Println("  " + ui.Clr.Green + c.name + ui.Clr.Reset + padding + c.description)
+		}
+	} else {
+		usage := strings.TrimSpace("wakafetch " + cmd.name + " " + cmd.args)
+		fmt.Println(ui.Clr.Bold + "Usage:" + ui.Clr.Reset + " " + usage + " [options]")
+		fmt.Println(cmd.description)
+	}
+
 	fmt.Println(ui.Clr.Bold + "Options:" + ui.Clr.Reset)
 
 	maxWidth := 0
-	for _, f := range registeredFlags {
-		width := len("-" + f.shortName + ", --" + f.longName + " " + f.flagType)
+	for _, f := range config.registeredFlags {
+		width := len("-x, --" + f.longName + " " + f.flagType)
 		if width > maxWidth {
 			maxWidth = width
 		}
 	}
 
-	for _, f := range registeredFlags {
+	for _, f := range config.registeredFlags {
 		flag := fmt.Sprintf("-%s, --%s", f.shortName, f.longName)
+		if f.shortName == "" {
+			flag = fmt.Sprintf("    --%s", f.longName)
+		}
 		flagLen := len(flag)
 		if f.flagType != "" {
 			flagLen = len(flag + " " + f.flagType)
@@ -108,3 +404,28 @@ func showCustomHelp() {
 	}
 	os.Exit(0)
 }
+
+// parseHeatmapLevels reads the least time for heatmap shades 1 to 4, like 30m,1h,2h,4h (or 0/30m/1h/2h/4h).
+// nil for quantile
+func parseHeatmapLevels(levels string) ([]float64, error) {
+	if levels == "quantile" {
+		return nil, nil
+	}
+	invalid := fmt.Errorf("Invalid --heatmap-levels: '%s', must be quantile or 4 increasing durations like 30m,1h,2h,4h", levels)
+	parts := strings.FieldsFunc(levels, func(r rune) bool { return r == ',' || r == '/' })
+	if len(parts) == 5 && strings.TrimSpace(parts[0]) == "0" {
+		parts = parts[1:]
+	}
+	if len(parts) != 4 {
+		return nil, invalid
+	}
+	var thresholds []float64
+	for _, part := range parts {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d < time.Minute || (len(thresholds) > 0 && d.Seconds() <= thresholds[len(thresholds)-1]) {
+			return nil, invalid
+		}
+		thresholds = append(thresholds, d.Seconds())
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/sahaj-b/wakafetch/types"
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+// --format flattens the responses into rows for spreadsheets:
+// - summaries: one row per day per dimension item (date, dimension, name, seconds)
+// - stats: one row per dimension item (dimension, name, seconds, percent)
+
+func commandFormats(config Config) []string {
+	if config.command.name == "export" {
+		return []string{"json", "csv", "tsv"}
+	}
+	return []string{"text", "json", "csv", "tsv", "svg", "markdown"}
+}
+
+func isValidFormat(config Config, format string) bool {
+	return slices.Contains(commandFormats(config), format)
+}
+
+func outputFormatted(w io.Writer, format string, data any) {
+	switch format {
+	case "json":
+		outputJSON(w, data)
+	case "csv", "tsv":
+		outputTable(w, format, data)
+	}
+}
+
+type dimension struct {
+	name  string
+	items []types.StatItem
+}
+
+// dimension names match the JSON keys of the API
+func dayDimensions(day types.DayData) []dimension {
+	return []dimension{
+		{"projects", day.Projects},
+		{"languages", day.Languages},
+		{"editors", day.Editors},
+		{"operating_systems", day.OperatingSystems},
+		{"machines", day.Machines},
+		{"categories", day.Categories},
+		{"branches", day.Branches},
+		{"entities", day.Entities},
+		{"dependencies", day.Dependencies},
+	}
+}
+
+func statsDimensions(stats *types.StatsResponse) []dimension {
+	d := stats.Data
+	return []dimension{
+		{"projects", d.Projects},
+		{"languages", d.Languages},
+		{"editors", d.Editors},
+		{"operating_systems", d.OperatingSystems},
+		{"machines", d.Machines},
+		{"categories", d.Categories},
+		{"branches", d.Branches},
+		{"dependencies", d.Dependencies},
+	}
+}
+
+func outputTable(w io.Writer, format string, data any) {
+	cw := csv.NewWriter(w)
+	if format == "tsv" {
+		cw.Comma = '\t'
+	}
+
+	switch data := data.(type) {
+	case *types.SummaryResponse:
+		cw.Write([]string{"date", "dimension", "name", "seconds"})
+		for _, day := range data.Data {
+			date := dayDate(day)
+			for _, dim := range dayDimensions(day) {
+				for _, item := range dim.items {
+					cw.Write([]string{date, dim.name, item.Name, secondsStr(item.TotalSeconds)})
+				}
+			}
+		}
+	case *types.StatsResponse:
+		cw.Write([]string{"dimension", "name", "seconds", "percent"})
+		for _, dim := range statsDimensions(data) {
+			// percent of the dimension's own total, categories and branches don't always add up to the grand total
+			var total float64
+			for _, item := range dim.items {
+				total += item.TotalSeconds
+			}
+			for _, item := range dim.items {
+				percent := 0.0
+				if total > 0 {
+					percent = item.TotalSeconds / total * 100
+				}
+				cw.Write([]string{dim.name, item.Name, secondsStr(item.TotalSeconds), strconv.FormatFloat(percent, 'f', 2, 64)})
+			}
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		ui.Errorln("Failed to write %s: %s", format, err.Error())
+	}
+}
+
+// whole seconds, the fractions are just noise in a spreadsheet
+func secondsStr(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 0, 64)
+}
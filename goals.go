@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+// goals live in the [goals] section of the config, one per key:
+//
+//	[goals]
+//	daily = 2h/day
+//	client = 10h/week project=client-x
+//	go = 5 days/week language=Go
+//
+// WakaTime's goals API is premium only and Wakapi has none, so they're evaluated here against /summaries
+
+type goal struct {
+	name   string
+	spec   string
+	target float64 // seconds, or days for days goals
+	days   bool    // counts active days instead of time
+	period string  // day or week
+	filter string  // dimension the goal is limited to, empty for the total
+	value  string
+}
+
+// goalFilters maps the filter keys in goal specs to the day's items
+var goalFilters = map[string]func(types.DayData) []types.StatItem{
+	"project":  func(d types.DayData) []types.StatItem { return d.Projects },
+	"language": func(d types.DayData) []types.StatItem { return d.Languages },
+	"editor":   func(d types.DayData) []types.StatItem { return d.Editors },
+	"category": func(d types.DayData) []types.StatItem { return d.Categories },
+	"machine":  func(d types.DayData) []types.StatItem { return d.Machines },
+	"branch":   func(d types.DayData) []types.StatItem { return d.Branches },
+}
+
+// configuredGoals is set by setupAPI from the config
+var configuredGoals []goal
+
+// parseGoals reads the [goals] section, sorted by name
+func parseGoals(section map[string]string) ([]goal, error) {
+	var goals []goal
+	for _, name := range slices.Sorted(maps.Keys(section)) {
+		g, err := parseGoal(name, section[name])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid goal '%s' in config: %w", name, err)
+		}
+		goals = append(goals, g)
+	}
+	return goals, nil
+}
+
+// parseGoal reads specs like `2h/day`, `10h/week project=client-x` or `5 days/week language=Go`
+func parseGoal(name, spec string) (goal, error) {
+	g := goal{name: name, spec: spec}
+	fields := strings.Fields(spec)
+	slash := slices.IndexFunc(fields, func(f string) bool { return strings.Contains(f, "/") })
+	if slash == -1 {
+		return g, fmt.Errorf("'%s' has no period, e.g. 2h/day or 10h/week", spec)
+	}
+
+	targetStr, period, _ := strings.Cut(strings.Join(fields[:slash+1], " "), "/")
+	g.period = period
+	if period != "day" && period != "week" {
+		return g, fmt.Errorf("unknown period '%s', must be day or week", period)
+	}
+
+	n, found := strings.CutSuffix(targetStr, "days")
+	if !found {
+		n, found = strings.CutSuffix(targetStr, "day")
+	}
+	if found {
+		days, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || days < 1 || days > 7 {
+			return g, fmt.Errorf("'%s' must be 1 to 7 days", targetStr)
+		}
+		if period != "week" {
+			return g, fmt.Errorf("days goals are per week, e.g. %d days/week", days)
+		}
+		g.target, g.days = float64(days), true
+	} else {
+		d, err := time.ParseDuration(strings.ReplaceAll(targetStr, " ", ""))
+		if err != nil || d < time.Minute {
+			return g, fmt.Errorf("'%s' isn't a duration like 2h or 1h30m", targetStr)
+		}
+		g.target = d.Seconds()
+	}
+
+	for _, f := range fields[slash+1:] {
+		if f == "total" {
+			continue
+		}
+		key, value, found := strings.Cut(f, "=")
+		if _, ok := goalFilters[key]; !found || !ok || value == "" {
+			return g, fmt.Errorf("unknown filter '%s', must be one of %s=NAME", f, strings.Join(slices.Sorted(maps.Keys(goalFilters)), "=NAME, "))
+		}
+		if g.filter != "" {
+			return g, fmt.Errorf("only one filter per goal is supported")
+		}
+		g.filter, g.value = key, value
+	}
+	return g, nil
+}
+
+// seconds is the time that counts toward the goal on a day
+func (g goal) seconds(day types.DayData) float64 {
+	if g.filter == "" {
+		return day.GrandTotal.TotalSeconds
+	}
+	total := 0.0
+	for _, item := range goalFilters[g.filter](day) {
+		if strings.EqualFold(item.Name, g.value) {
+			total += item.TotalSeconds
+		}
+	}
+	return total
+}
+
+// progress is the goal's amount over some days, in seconds or active days (with at least minActive seconds)
+func (g goal) progress(days []types.DayData, minActive float64) float64 {
+	done := 0.0
+	for _, day := range days {
+		secs := g.seconds(day)
+		if !g.days {
+			done += secs
+		} else if secs >= minActive {
+			done++
+		}
+	}
+	return done
+}
+
+func runGoals(config Config) {
+	if len(config.args) > 0 {
+		ui.Errorln("Unknown argument: '%s'", config.args[0])
+	}
+	profiles := setupAPI(config)
+	if len(configuredGoals) == 0 {
+		ui.Errorln("No goals configured, add them to a [goals] section in %s, e.g. daily = 2h/day", getWakafetchConfigPath())
+	}
+	if *config.historyFlag < 1 {
+		ui.Errorln("Invalid value for --history: must be a positive integer")
+	}
+
+	// start on a monday, so every week in the history is complete
+	dr := lastNDays(*config.historyFlag)
+	dr.start = weekStart(dr.start)
+	data, err := fetchSummaryAll(profiles, dr.start, dr.end, "")
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+
+	byDate := make(map[string]types.DayData)
+	for _, day := range data.Data {
+		byDate[dayDate(day)] = day
+	}
+	// every day of the range, days without activity included
+	var days []types.DayData
+	for d := dr.start; !d.After(dr.end); d = d.AddDate(0, 0, 1) {
+		day := byDate[d.Format(dateLayout)]
+		day.Range.Date = d.Format(dateLayout)
+		days = append(days, day)
+	}
+
+	var results []ui.GoalProgress
+	for _, g := range configuredGoals {
+		results = append(results, evaluateGoal(g, days, config.minActiveFlag.Seconds()))
+	}
+	heading := fmt.Sprintf("Goals (%s to %s)", dr.start.Format("Jan 2"), dr.end.Format("Jan 2"))
+	ui.DisplayGoals(results, heading)
+}
+
+// evaluateGoal splits the days into the goal's periods, the last one being the current (unfinished) one
+func evaluateGoal(g goal, days []types.DayData, minActive float64) ui.GoalProgress {
+	var periods [][]types.DayData
+	for i, day := range days {
+		if g.period == "day" || i%7 == 0 {
+			periods = append(periods, nil)
+		}
+		periods[len(periods)-1] = append(periods[len(periods)-1], day)
+	}
+
+	met := make([]bool, len(periods))
+	hit := 0
+	for i, p := range periods {
+		met[i] = g.progress(p, minActive) >= g.target
+		if met[i] {
+			hit++
+		}
+	}
+
+	// the current period isn't a miss (or a broken streak) until it's over
+	last := len(periods) - 1
+	if !met[last] {
+		last--
+	}
+	streak := 0
+	for i := last; i >= 0 && met[i]; i-- {
+		streak++
+	}
+
+	return ui.GoalProgress{
+		Name:   g.name,
+		Spec:   g.spec,
+		Done:   g.progress(periods[len(periods)-1], minActive),
+		Target: g.target,
+		Days:   g.days,
+		Met:    met[len(met)-1],
+		Period: g.period,
+		Streak: streak,
+		Hit:    hit,
+		Total:  last + 1,
+	}
+}
+
+// dailyGoalChecks are the per day goals, for the pass/fail columns in the daily breakdown
+func dailyGoalChecks(goals []goal) []ui.DayGoal {
+	var checks []ui.DayGoal
+	for _, g := range goals {
+		if g.period == "day" {
+			checks = append(checks, ui.DayGoal{Name: g.name, Met: func(day types.DayData) bool { return g.seconds(day) >= g.target }})
+		}
+	}
+	return checks
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// iniFile maps section -> key -> value. Keys before any section header go in the "" section
+type iniFile map[string]map[string]string
+
+func (f iniFile) get(section, key string) string {
+	return f[section][key]
+}
+
+// merge copies every key of other into f, overriding existing ones
+func (f iniFile) merge(other iniFile) {
+	for section, values := range other {
+		if f[section] == nil {
+			f[section] = make(map[string]string)
+		}
+		for key, val := range values {
+			f[section][key] = val
+		}
+	}
+}
+
+// parseINI reads an INI file the way wakatime-cli does:
+// - `#` and `;` start comments, at the start of a line or after whitespace
+// - indented lines continue the previous value (used for multi-line include/exclude lists)
+// - `import_cfg` in [settings] pulls in another file, whose values take precedence
+func parseINI(path string) (iniFile, error) {
+	return parseINIFile(path, make(map[string]bool))
+}
+
+func parseINIFile(path string, visited map[string]bool) (iniFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if visited[absPath] {
+		return nil, fmt.Errorf("import_cfg loop: %s is imported more than once", path)
+	}
+	visited[absPath] = true
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ini := iniFile{"": {}}
+	section, lastKey := "", ""
+	scanner := bufio.NewScanner(file)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		raw := scanner.Text()
+		line := stripINIComment(strings.TrimSpace(raw))
+		if line == "" {
+			continue
+		}
+
+		if lastKey != "" && (raw[0] == ' ' || raw[0] == '\t') {
+			prev := ini[section][lastKey]
+			if prev != "" {
+				prev += "\n"
+			}
+			ini[section][lastKey] = prev + line
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: malformed section header '%s'", path, lineNum, line)
+			}
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			if ini[section] == nil {
+				ini[section] = make(map[string]string)
+			}
+			lastKey = ""
+			continue
+		}
+
+		key, val, found := strings.Cut(line, "=")
+		if !found {
+			continue // skip junk lines rather than rejecting a config other tools are happy with
+		}
+		lastKey = strings.TrimSpace(key)
+		ini[section][lastKey] = unquote(strings.TrimSpace(val))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if importPath := ini.get("settings", "import_cfg"); importPath != "" {
+		importPath = expandHome(importPath)
+		if !filepath.IsAbs(importPath) {
+			importPath = filepath.Join(filepath.Dir(path), importPath)
+		}
+		imported, err := parseINIFile(importPath, visited)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import_cfg %s: %w", importPath, err)
+		}
+		ini.merge(imported)
+	}
+
+	return ini, nil
+}
+
+// stripINIComment removes trailing `# comment`/`; comment`, which need whitespace before them
+// so values like URLs with fragments survive
+func stripINIComment(line string) string {
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+		return ""
+	}
+	for i := 1; i < len(line); i++ {
+		if (line[i] == '#' || line[i] == ';') && (line[i-1] == ' ' || line[i-1] == '\t') {
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return line
+}
+
+func unquote(val string) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[1:])
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
+	"github.com/sahaj-b/wakafetch/types"
 	"github.com/sahaj-b/wakafetch/ui"
 )
 
@@ -13,117 +15,237 @@ import (
 // - gives summary of EACH day, so more granular data
 // - have to aggregate data manually for viewing stats
 // - supports custom date ranges
-// - so, its called when --days(custom range) or --daily/heatmap(granular daily breakdown) is used
+// - supports filtering by project
+// - so, its called when --days/--from/--month/--week(custom range), --daily/heatmap(granular daily breakdown), --project, --compare or --hours is used
 
 // /stats response:
 // - gives summary of the ENTIRE range in a single response
 // - no need for aggregation, efficient af
 // - doesn't support custom date ranges(only rangeStr)
-// - so, its the default unless --days or --daily/heatmap is used
+// - so, its the default unless a custom range, --daily/heatmap, --project, --compare or --hours is used
 
 func main() {
-	config := parseFlags()
-	apiURL, apiKey := loadAPIConfig(config)
+	config := parseFlags(os.Args[1:])
+	config.command.run(config)
+}
 
-	if shouldUseSummaryAPI(config) {
-		handleSummaryFlow(config, apiKey, apiURL)
-	} else {
-		handleStatsFlow(config, apiKey, apiURL)
+// setupAPI loads the config files for commands that talk to the API, returning the profiles to fetch
+func setupAPI(config Config) []apiProfile {
+	fileCfg, err := parseConfig(*config.configFlag)
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+	applyConfigDefaults(config, fileCfg.wakafetch)
+	validateFlags(config)
+	applyFetchOptions(config, fileCfg)
+
+	if config.hasFlag("min-active") {
+		ui.SetMinActive(*config.minActiveFlag)
 	}
+	if config.hasFlag("theme") {
+		levels, err := parseHeatmapLevels(*config.heatLevelsFlag)
+		if err != nil {
+			ui.Errorln(err.Error())
+		}
+		ui.SetHeatmapStyle(*config.themeFlag, levels)
+	}
+	configuredGoals = fileCfg.goals
+	if *config.projectFlag == "" {
+		// pass/fail columns in the daily breakdown, goals don't apply to a single project's days
+		ui.SetDayGoals(dailyGoalChecks(fileCfg.goals))
+	}
+	return selectProfiles(config, fileCfg)
 }
 
-func loadAPIConfig(config Config) (string, string) {
-	apiURL, apiKey, err := parseConfig()
-	if err != nil {
+// applyFetchOptions sets up the http client, caching and retries
+func applyFetchOptions(config Config, fileCfg fileConfig) {
+	if err := configureHTTPClient(fileCfg); err != nil {
 		ui.Errorln(err.Error())
 	}
 
-	if *config.apiKeyFlag != "" {
-		apiKey = *config.apiKeyFlag
+	cacheOpts = cacheOptions{
+		ttl:     *config.cacheTTL,
+		offline: *config.offlineFlag,
+		refresh: *config.refreshFlag,
 	}
 
-	return apiURL, apiKey
+	retryOpts = retryPolicy{maxRetries: *config.retriesFlag, maxWait: *config.retryMaxWait}
 }
 
 func shouldUseSummaryAPI(config Config) bool {
-	return *config.daysFlag != 0 || *config.dailyFlag || *config.heatmapFlag
+	return *config.daysFlag != 0 || hasCustomRange(config) || *config.dailyFlag || *config.heatmapFlag || *config.projectFlag != "" || *config.compareFlag || *config.hoursFlag
+}
+
+// view is one fetch of what the command shows: the raw data for the data formats,
+// display for the cards, and the total time for the --watch delta
+type view struct {
+	data    any
+	display func()
+	total   float64
 }
 
-func handleStatsFlow(config Config, apiKey, apiURL string) {
+func fetchView(config Config, profiles []apiProfile) (view, error) {
+	if shouldUseSummaryAPI(config) {
+		return handleSummaryFlow(config, profiles)
+	}
+	return handleStatsFlow(config, profiles)
+}
+
+func handleStatsFlow(config Config, profiles []apiProfile) (view, error) {
 	rangeStr := getRangeStr(*config.rangeFlag)
 
-	data, err := fetchStats(apiKey, apiURL, rangeStr)
+	data, err := fetchStatsAll(profiles, rangeStr)
 	if err != nil {
-		ui.Errorln(err.Error())
+		return view{}, err
 	}
 
-	if *config.jsonFlag {
-		outputJSON(data)
-		return
+	display := func() { ui.DisplayStats(data, *config.fullFlag, rangeStr) }
+	return view{data, display, data.Data.TotalSeconds}, nil
+}
+
+// maxHoursDays caps the range of --hours, which fetches /durations once per day and profile
+const maxHoursDays = 31
+
+func handleSummaryFlow(config Config, profiles []apiProfile) (view, error) {
+	dr := summaryDateRange(config)
+
+	project := *config.projectFlag
+	data, err := fetchSummaryAll(profiles, dr.start, dr.end, project)
+	if err != nil {
+		return view{}, err
 	}
 
-	ui.DisplayStats(data, *config.fullFlag, rangeStr)
-}
+	var prevData *types.SummaryResponse
+	prev := dr.previous()
+	if *config.compareFlag {
+		prevData, err = fetchSummaryAll(profiles, prev.start, prev.end, project)
+		if err != nil {
+			return view{}, err
+		}
+	}
 
-func handleSummaryFlow(config Config, apiKey, apiURL string) {
-	rangeStr := getRangeStr(*config.rangeFlag)
-	days := *config.daysFlag
-	validRange := true
-	if days == 0 {
-		days, validRange = map[string]int{
-			"today":         1,
-			"last_7_days":   7,
-			"last_30_days":  30,
-			"last_6_months": 183,
-			"last_year":     365,
-		}[rangeStr]
+	var hours []float64
+	if *config.hoursFlag && dr.days() > maxHoursDays {
+		ui.Warnln("Skipping the By Hour card, --hours takes ranges of up to %d days (one request per day)", maxHoursDays)
+	} else if *config.hoursFlag {
+		durations, err := fetchDurationsAll(profiles, dr.start, dr.end, project)
+		if err != nil {
+			ui.Warnln("Skipping the By Hour card, %s", err.Error())
+		}
+		hours = ui.HourTotals(durations)
 	}
 
-	if !validRange {
-		ui.Errorln("This range isn't supported with `--daily` or `--heatmap` flags. Use `--days` instead")
-		return
+	heading := breakdownHeading(dr)
+	if project != "" {
+		heading = project + ": " + heading
+	}
+	payload := func(data *types.SummaryResponse) *ui.DisplayPayload {
+		if project != "" {
+			return ui.ProjectPayload(data, project, dr.label)
+		}
+		return ui.SummaryPayload(data, *config.fullFlag, dr.label)
+	}
+	display := func() {
+		switch {
+		case *config.dailyFlag:
+			ui.DisplayBreakdown(data.Data, heading)
+		case *config.heatmapFlag:
+			ui.DisplayHeatmap(data.Data, heading)
+		case *config.compareFlag:
+			prevRange := fmt.Sprintf("%s to %s", prev.start.Format("Jan 2"), prev.end.Format("Jan 2"))
+			p := payload(data)
+			if p != nil {
+				p.Hours = hours
+			}
+			ui.DisplayComparison(p, payload(prevData), prevRange)
+		case project != "":
+			ui.DisplayProject(data, project, dr.label, hours)
+		default:
+			ui.DisplaySummary(data, *config.fullFlag, dr.label, hours)
+		}
 	}
+	return view{data, display, data.CumulativeTotal.Seconds}, nil
+}
 
-	data, err := fetchSummary(apiKey, apiURL, days)
-	if err != nil {
-		ui.Errorln(err.Error())
+// outputView prints the cards for text, markdown and svg, the data itself for the other formats
+func outputView(config Config, data any, display func()) {
+	switch *config.formatFlag {
+	case "text":
+		display()
+	case "markdown":
+		ui.UseMarkdown()
+		display()
+	case "svg":
+		if !*config.noColorFlag {
+			ui.EnableColors() // usually redirected to a file, the tty check would have turned them off
+		}
+		if err := ui.WriteSVG(os.Stdout, *config.svgThemeFlag, display); err != nil {
+			ui.Errorln("Failed to render SVG: %s", err.Error())
+		}
+	default:
+		outputFormatted(os.Stdout, *config.formatFlag, data)
 	}
+}
 
-	if *config.jsonFlag {
-		outputJSON(data)
-		return
+// summaryDateRange resolves the days to fetch from --from/--to, --month, --week, --days or --range
+func summaryDateRange(config Config) dateRange {
+	if hasCustomRange(config) {
+		dr, err := parseCustomRange(config)
+		if err != nil {
+			ui.Errorln(err.Error())
+		}
+		return dr
 	}
 
-	var heading string
-	if *config.daysFlag != 0 {
+	if days := *config.daysFlag; days != 0 {
+		dr := lastNDays(days)
 		if days == 1 {
-			heading = "Today"
+			dr.label = "Today"
 		} else {
-			heading = fmt.Sprintf("Last %d days", days)
-		}
-	} else {
-		headingMap := map[string]string{
-			"today":         "Today",
-			"last_7_days":   "Last 7 days",
-			"last_30_days":  "Last 30 days",
-			"last_6_months": "Last 6 months",
-			"last_year":     "Last year",
-			"all_time":      "All time",
+			dr.label = fmt.Sprintf("Last %d days", days)
 		}
-		heading = headingMap[rangeStr]
+		return dr
 	}
 
-	if *config.dailyFlag {
-		ui.DisplayBreakdown(data.Data, heading)
-		return
+	dr, validRange := rangeStrDateRange(getRangeStr(*config.rangeFlag))
+	if !validRange {
+		ui.Errorln("This range isn't supported with `--daily`, `--heatmap`, `--project`, `--compare` or `--hours`. Use `--days` or `--from` instead")
 	}
+	return dr
+}
 
-	if *config.heatmapFlag {
-		ui.DisplayHeatmap(data.Data, heading)
-		return
+// rangeStrDateRange turns a /stats range into days, for the summaries API. all_time has no fixed start, so it's not supported
+func rangeStrDateRange(rangeStr string) (dateRange, bool) {
+	days, validRange := map[string]int{
+		"today":         1,
+		"last_7_days":   7,
+		"last_30_days":  30,
+		"last_6_months": 183,
+		"last_year":     365,
+	}[rangeStr]
+	if !validRange {
+		return dateRange{}, false
 	}
+	dr := lastNDays(days)
+	dr.label = map[string]string{
+		"today":         "Today",
+		"last_7_days":   "Last 7 days",
+		"last_30_days":  "Last 30 days",
+		"last_6_months": "Last 6 months",
+		"last_year":     "Last year",
+	}[rangeStr]
+	return dr, true
+}
 
-	ui.DisplaySummary(data, *config.fullFlag, heading)
+// breakdownHeading includes the dates for custom ranges, since labels like "Custom range" say nothing on their own
+func breakdownHeading(dr dateRange) string {
+	if !dr.custom {
+		return dr.label
+	}
+	if dr.days() == 1 {
+		return fmt.Sprintf("%s (%s)", dr.label, dr.start.Format("Jan 2"))
+	}
+	return fmt.Sprintf("%s (%s to %s)", dr.label, dr.start.Format("Jan 2"), dr.end.Format("Jan 2"))
 }
 
 func getRangeStr(rangeFlag string) string {
@@ -159,10 +281,10 @@ func colorsShouldBeEnabled() bool {
 	return (file.Mode() & os.ModeCharDevice) != 0
 }
 
-func outputJSON(data any) {
+func outputJSON(w io.Writer, data any) {
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		ui.Errorln("Failed to marshal JSON: %s", err.Error())
 	}
-	fmt.Println(string(jsonData))
+	fmt.Fprintln(w, string(jsonData))
 }
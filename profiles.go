@@ -0,0 +1,293 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+// apiProfile is a profile with its api key resolved
+type apiProfile struct {
+	name   string
+	apiURL string
+	apiKey string
+}
+
+// selectProfiles returns the profile picked with --profile, or every profile with --all-profiles
+func selectProfiles(config Config, fileCfg fileConfig) []apiProfile {
+	if !*config.allProfilesFlag {
+		name := strings.ToLower(*config.profileFlag)
+		if name == "" || name == "default" {
+			return []apiProfile{loadAPIConfig(config, fileCfg.defaultProfile)}
+		}
+		for _, p := range fileCfg.profiles {
+			if p.name == name {
+				return []apiProfile{loadAPIConfig(config, p)}
+			}
+		}
+		ui.Errorln("Unknown profile: '%s', available: %s", name, strings.Join(profileNames(fileCfg), ", "))
+	}
+
+	if *config.profileFlag != "" {
+		ui.Errorln("--profile and --all-profiles can't be used together")
+	}
+	if *config.apiKeyFlag != "" || *config.apiKeyFileFlag != "" {
+		ui.Errorln("--api-key and --api-key-file can't be used with --all-profiles")
+	}
+
+	var profiles []apiProfile
+	// the default profile is only included if it's actually set up
+	if fileCfg.defaultProfile.apiURL != "" {
+		profiles = append(profiles, loadAPIConfig(config, fileCfg.defaultProfile))
+	}
+	for _, p := range fileCfg.profiles {
+		profiles = append(profiles, loadAPIConfig(config, p))
+	}
+	if len(profiles) == 0 {
+		ui.Errorln("No profiles found. Add [wakafetch.<name>] sections with api_url and api_key to your config")
+	}
+	return profiles
+}
+
+func profileNames(fileCfg fileConfig) []string {
+	names := []string{"default"}
+	for _, p := range fileCfg.profiles {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// loadAPIConfig resolves the API key of a profile, first one wins:
+// --api-key, --api-key-file, $WAKAFETCH_API_KEY, $WAKATIME_API_KEY, api_key, api_key_vault_cmd
+// The env vars only apply to the default profile, they're meant for the wakatime-cli account
+func loadAPIConfig(config Config, p profileConfig) apiProfile {
+	section := "[settings]"
+	if p.name != "default" {
+		section = "[wakafetch." + p.name + "]"
+	}
+	profile := apiProfile{name: p.name, apiURL: p.apiURL}
+	if profile.apiURL == "" {
+		ui.Errorln("api_url not found in %s of config", section)
+	}
+
+	if *config.apiKeyFlag != "" {
+		profile.apiKey = *config.apiKeyFlag
+		return profile
+	}
+
+	if *config.apiKeyFileFlag != "" {
+		raw, err := os.ReadFile(expandHome(*config.apiKeyFileFlag))
+		if err != nil {
+			ui.Errorln("Failed to read --api-key-file: %s", err.Error())
+		}
+		profile.apiKey = strings.TrimSpace(string(raw))
+		if profile.apiKey == "" {
+			ui.Errorln("--api-key-file is empty: %s", *config.apiKeyFileFlag)
+		}
+		return profile
+	}
+
+	if p.name == "default" {
+		for _, env := range []string{"WAKAFETCH_API_KEY", "WAKATIME_API_KEY"} {
+			if apiKey := strings.TrimSpace(os.Getenv(env)); apiKey != "" {
+				profile.apiKey = apiKey
+				return profile
+			}
+		}
+	}
+
+	if p.apiKey != "" {
+		profile.apiKey = p.apiKey
+		return profile
+	}
+
+	if p.apiKeyVaultCmd != "" {
+		apiKey, err := runVaultCmd(p.apiKeyVaultCmd)
+		if err != nil {
+			ui.Errorln("%s (%s)", err.Error(), section)
+		}
+		profile.apiKey = apiKey
+		return profile
+	}
+
+	ui.Errorln("api_key not found in %s. Set api_key or api_key_vault_cmd in config, $WAKATIME_API_KEY, or use --api-key", section)
+	return profile
+}
+
+// profileError is a profile skipped by the *All fetches, the others still made it into the result
+type profileError struct {
+	name string
+	err  error
+}
+
+func warnSkipped(skipped []profileError) {
+	for _, s := range skipped {
+		ui.Warnln("Skipping profile '%s': %s", s.name, s.err.Error())
+	}
+}
+
+// fetchStatsAll merges the stats of every profile. A failing profile is skipped with a warning,
+// so one server being down doesn't hide the rest
+func fetchStatsAll(profiles []apiProfile, rangeStr string) (*types.StatsResponse, error) {
+	data, skipped, err := fetchStatsEach(profiles, rangeStr)
+	warnSkipped(skipped)
+	return data, err
+}
+
+// fetchStatsEach is fetchStatsAll returning the skipped profiles instead of warning about them
+func fetchStatsEach(profiles []apiProfile, rangeStr string) (*types.StatsResponse, []profileError, error) {
+	if len(profiles) == 1 {
+		data, err := fetchStats(profiles[0].apiKey, profiles[0].apiURL, rangeStr)
+		return data, nil, err
+	}
+
+	var responses []*types.StatsResponse
+	var skipped []profileError
+	for _, p := range profiles {
+		data, err := fetchStats(p.apiKey, p.apiURL, rangeStr)
+		if err != nil {
+			skipped = append(skipped, profileError{p.name, err})
+			continue
+		}
+		responses = append(responses, data)
+	}
+	if len(responses) == 0 {
+		return nil, skipped, fmt.Errorf("failed to fetch stats from every profile")
+	}
+	return mergeStats(responses), skipped, nil
+}
+
+// fetchSummaryAll merges the days of every profile, skipping failing ones like fetchStatsAll
+func fetchSummaryAll(profiles []apiProfile, start, end time.Time, project string) (*types.SummaryResponse, error) {
+	data, skipped, err := fetchSummaryEach(profiles, start, end, project)
+	warnSkipped(skipped)
+	return data, err
+}
+
+// fetchSummaryEach is fetchSummaryAll returning the skipped profiles instead of warning about them
+func fetchSummaryEach(profiles []apiProfile, start, end time.Time, project string) (*types.SummaryResponse, []profileError, error) {
+	if len(profiles) == 1 {
+		data, err := fetchSummary(profiles[0].apiKey, profiles[0].apiURL, start, end, project)
+		return data, nil, err
+	}
+
+	days := make(map[string]types.DayData)
+	var skipped []profileError
+	for _, p := range profiles {
+		data, err := fetchSummary(p.apiKey, p.apiURL, start, end, project)
+		if err != nil {
+			skipped = append(skipped, profileError{p.name, err})
+			continue
+		}
+		for _, day := range data.Data {
+			date := dayDate(day)
+			if existing, ok := days[date]; ok {
+				day = mergeDays(existing, day)
+			}
+			days[date] = day
+		}
+	}
+	if len(skipped) == len(profiles) {
+		return nil, skipped, fmt.Errorf("failed to fetch stats from every profile")
+	}
+	return buildSummaryResponse(days, start, end), skipped, nil
+}
+
+// fetchDurationsAll collects the sessions of every day from start to end, of every profile.
+// Unlike the others it fails on the first error, it's usually the server not having the endpoint
+func fetchDurationsAll(profiles []apiProfile, start, end time.Time, project string) ([]types.Duration, error) {
+	var durations []types.Duration
+	for _, p := range profiles {
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			data, err := fetchDurations(p.apiKey, p.apiURL, d, project)
+			if err != nil {
+				return nil, err
+			}
+			durations = append(durations, data.Data...)
+		}
+	}
+	return durations, nil
+}
+
+func mergeStats(responses []*types.StatsResponse) *types.StatsResponse {
+	merged := *responses[0]
+	m := &merged.Data
+	for _, r := range responses[1:] {
+		d := r.Data
+		m.Branches = mergeStatItems(m.Branches, d.Branches)
+		m.Categories = mergeStatItems(m.Categories, d.Categories)
+		m.Dependencies = mergeStatItems(m.Dependencies, d.Dependencies)
+		m.Editors = mergeStatItems(m.Editors, d.Editors)
+		m.Languages = mergeStatItems(m.Languages, d.Languages)
+		m.Machines = mergeStatItems(m.Machines, d.Machines)
+		m.OperatingSystems = mergeStatItems(m.OperatingSystems, d.OperatingSystems)
+		m.Projects = mergeStatItems(m.Projects, d.Projects)
+		m.TotalSeconds += d.TotalSeconds
+		m.DailyAverage += d.DailyAverage
+		m.DaysIncludingHolidays = max(m.DaysIncludingHolidays, d.DaysIncludingHolidays)
+		if d.Start != "" && (m.Start == "" || d.Start < m.Start) {
+			m.Start = d.Start
+		}
+		if d.End > m.End {
+			m.End = d.End
+		}
+	}
+	m.HumanReadableTotal = textFmt(m.TotalSeconds)
+	m.HumanReadableDailyAverage = textFmt(m.DailyAverage)
+	return &merged
+}
+
+func mergeDays(a, b types.DayData) types.DayData {
+	a.Entities = mergeStatItems(a.Entities, b.Entities)
+	a.Branches = mergeStatItems(a.Branches, b.Branches)
+	a.Categories = mergeStatItems(a.Categories, b.Categories)
+	a.Dependencies = mergeStatItems(a.Dependencies, b.Dependencies)
+	a.Editors = mergeStatItems(a.Editors, b.Editors)
+	a.Languages = mergeStatItems(a.Languages, b.Languages)
+	a.Machines = mergeStatItems(a.Machines, b.Machines)
+	a.OperatingSystems = mergeStatItems(a.OperatingSystems, b.OperatingSystems)
+	a.Projects = mergeStatItems(a.Projects, b.Projects)
+	a.GrandTotal.TotalSeconds += b.GrandTotal.TotalSeconds
+	secs := int(a.GrandTotal.TotalSeconds)
+	a.GrandTotal.Hours = secs / 3600
+	a.GrandTotal.Minutes = (secs % 3600) / 60
+	a.GrandTotal.Digital = digitalFmt(a.GrandTotal.TotalSeconds)
+	a.GrandTotal.Text = textFmt(a.GrandTotal.TotalSeconds)
+	return a
+}
+
+// mergeStatItems sums items with the same name, sorted by time descending
+func mergeStatItems(lists ...[]types.StatItem) []types.StatItem {
+	totals := make(map[string]float64)
+	for _, list := range lists {
+		for _, item := range list {
+			totals[item.Name] += item.TotalSeconds
+		}
+	}
+	total := 0.0
+	for _, seconds := range totals {
+		total += seconds
+	}
+	// percentages of the merged total, each profile's own ones don't add up
+	items := make([]types.StatItem, 0, len(totals))
+	for name, seconds := range totals {
+		item := types.StatItem{Name: name, TotalSeconds: seconds}
+		if total > 0 {
+			item.Percent = seconds / total * 100
+		}
+		items = append(items, item)
+	}
+	slices.SortStableFunc(items, func(a, b types.StatItem) int {
+		if c := cmp.Compare(b.TotalSeconds, a.TotalSeconds); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return items
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requests that fail with something temporary (timeouts, 429, 502/503/504) or come back as 202
+// (wakatime's "still calculating your stats") are retried with jittered exponential backoff
+
+type retryPolicy struct {
+	maxRetries int
+	maxWait    time.Duration // cap for a single wait, longer Retry-After values make us give up instead
+}
+
+var retryOpts = retryPolicy{maxRetries: 3, maxWait: 30 * time.Second}
+
+const retryBaseWait = 500 * time.Millisecond
+
+// backoff returns how long to wait before retry number attempt (0 based), and false if it's not worth waiting
+func (p retryPolicy) backoff(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if attempt >= p.maxRetries {
+		return 0, false
+	}
+	if retryAfter > 0 {
+		return retryAfter, retryAfter <= p.maxWait
+	}
+	// doubling stops at maxWait, shifting by attempt would overflow with enough --retries
+	wait := retryBaseWait
+	for i := 0; i < attempt && wait < p.maxWait; i++ {
+		wait *= 2
+	}
+	wait = min(wait, p.maxWait)
+	// "equal jitter": at least half the backoff, so concurrent runs don't retry in lockstep
+	half := int64(wait / 2)
+	if half <= 0 {
+		return 0, true
+	}
+	return time.Duration(half + rand.Int64N(half+1)), true
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusAccepted, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter handles both forms of the header: delay in seconds or an HTTP date
+func parseRetryAfter(header string) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
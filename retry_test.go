@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffJitter(t *testing.T) {
+	p := retryPolicy{maxRetries: 5, maxWait: 2 * time.Second}
+	for attempt := range 5 {
+		full := min(retryBaseWait<<attempt, p.maxWait)
+		for range 100 {
+			wait, ok := p.backoff(attempt, 0)
+			if !ok {
+				t.Fatalf("attempt %d: gave up before maxRetries", attempt)
+			}
+			if wait < full/2 || wait > full {
+				t.Fatalf("attempt %d: wait %s outside [%s, %s]", attempt, wait, full/2, full)
+			}
+		}
+	}
+	if _, ok := p.backoff(5, 0); ok {
+		t.Fatal("retried past maxRetries")
+	}
+}
+
+func TestBackoffManyRetries(t *testing.T) {
+	p := retryPolicy{maxRetries: 100, maxWait: 30 * time.Second}
+	if wait, ok := p.backoff(99, 0); !ok || wait < p.maxWait/2 || wait > p.maxWait {
+		t.Fatalf("attempt 99: got %s, %v", wait, ok)
+	}
+	p.maxWait = 0
+	if wait, ok := p.backoff(0, 0); !ok || wait != 0 {
+		t.Fatalf("no maxWait: got %s, %v", wait, ok)
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	p := retryPolicy{maxRetries: 3, maxWait: 10 * time.Second}
+	if wait, ok := p.backoff(0, 5*time.Second); !ok || wait != 5*time.Second {
+		t.Fatalf("Retry-After 5s: got %s, %v", wait, ok)
+	}
+	if _, ok := p.backoff(0, time.Minute); ok {
+		t.Fatal("waited for a Retry-After longer than maxWait")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("7"); got != 7*time.Second {
+		t.Fatalf("seconds: got %s", got)
+	}
+	date := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got < 28*time.Second || got > 30*time.Second {
+		t.Fatalf("http date: got %s", got)
+	}
+	past := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	for _, header := range []string{"", "soon", "-3", past} {
+		if got := parseRetryAfter(header); got != 0 {
+			t.Fatalf("%q: got %s, want 0", header, got)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+// `wakafetch serve` refreshes the stats every --interval and serves them in the Prometheus text format on /metrics.
+// Scrapes always get the last snapshot, so a slow or failing API never makes them time out
+
+// metric dimension labels are singular, like the API field names
+var metricDimensions = map[string]string{
+	"projects":          "project",
+	"languages":         "language",
+	"editors":           "editor",
+	"operating_systems": "operating_system",
+	"machines":          "machine",
+	"categories":        "category",
+	"branches":          "branch",
+	"dependencies":      "dependency",
+}
+
+type metricLabel struct {
+	name  string
+	value string
+}
+
+type metricSample struct {
+	labels []metricLabel
+	value  float64
+}
+
+func runServe(config Config) {
+	if len(config.args) > 0 {
+		ui.Errorln("Unknown argument: '%s'", config.args[0])
+	}
+	profiles := setupAPI(config)
+
+	if *config.intervalFlag < time.Minute {
+		ui.Errorln("Invalid value for --interval: must be at least 1m")
+	}
+	if *config.dailyDaysFlag < 1 {
+		ui.Errorln("Invalid value for --daily-days: must be a positive integer")
+	}
+	var ranges []string
+	for r := range strings.SplitSeq(*config.rangesFlag, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			ranges = append(ranges, getRangeStr(r))
+		}
+	}
+
+	serveCacheOptions(*config.intervalFlag)
+
+	metrics := &metricsHandler{}
+	go func() {
+		for {
+			metrics.refresh(profiles, ranges, *config.dailyDaysFlag)
+			time.Sleep(*config.intervalFlag)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	// scrapes are tiny, slow clients shouldn't be able to hold connections open
+	server := &http.Server{
+		Addr:              *config.metricsFlag,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	fmt.Printf("Serving metrics on %s/metrics, refreshing every %s\n", server.Addr, *config.intervalFlag)
+	if err := server.ListenAndServe(); err != nil {
+		ui.Errorln("Failed to serve metrics: %s", err.Error())
+	}
+}
+
+// serveCacheOptions makes every refresh hit the API. Metrics from stale cache would keep
+// wakafetch_up at 1 while the server is down
+func serveCacheOptions(interval time.Duration) {
+	refreshEvery(interval)
+	cacheOpts.noStale = true
+}
+
+// metricsHandler serves the last snapshot, 503 until the first refresh is done
+type metricsHandler struct {
+	mu       sync.RWMutex
+	snapshot []byte
+}
+
+func (m *metricsHandler) refresh(profiles []apiProfile, ranges []string, days int) {
+	body := collectMetrics(profiles, ranges, days)
+	m.mu.Lock()
+	m.snapshot = body
+	m.mu.Unlock()
+}
+
+func (m *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.snapshot == nil {
+		http.Error(w, "The first refresh hasn't finished yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(m.snapshot)
+}
+
+// collectMetrics fetches everything and renders it. Failed fetches are left out and set wakafetch_up to 0,
+// so does a single profile failing with --all-profiles, the metrics are missing its time
+func collectMetrics(profiles []apiProfile, ranges []string, days int) []byte {
+	up := 1.0
+	var seconds, rangeSeconds, dailySeconds []metricSample
+	skippedProfiles := func(skipped []profileError) {
+		if len(skipped) > 0 {
+			warnSkipped(skipped)
+			up = 0
+		}
+	}
+
+	for _, rangeStr := range ranges {
+		data, skipped, err := fetchStatsEach(profiles, rangeStr)
+		skippedProfiles(skipped)
+		if err != nil {
+			ui.Warnln("Failed to refresh %s stats: %s", rangeStr, err.Error())
+			up = 0
+			continue
+		}
+		rangeSeconds = append(rangeSeconds, metricSample{[]metricLabel{{"range", rangeStr}}, data.Data.TotalSeconds})
+		for _, dim := range statsDimensions(data) {
+			for _, item := range dim.items {
+				labels := []metricLabel{{"dimension", metricDimensions[dim.name]}, {"name", item.Name}, {"range", rangeStr}}
+				seconds = append(seconds, metricSample{labels, item.TotalSeconds})
+			}
+		}
+	}
+
+	dr := lastNDays(days)
+	data, skipped, err := fetchSummaryEach(profiles, dr.start, dr.end, "")
+	skippedProfiles(skipped)
+	if err != nil {
+		ui.Warnln("Failed to refresh daily summaries: %s", err.Error())
+		up = 0
+	} else {
+		for _, day := range data.Data {
+			dailySeconds = append(dailySeconds, metricSample{[]metricLabel{{"date", dayDate(day)}}, day.GrandTotal.TotalSeconds})
+		}
+	}
+
+	var b strings.Builder
+	writeMetric(&b, "wakafetch_seconds_total", "Coding time in seconds per dimension item over a range", seconds)
+	writeMetric(&b, "wakafetch_range_seconds", "Total coding time in seconds over a range", rangeSeconds)
+	writeMetric(&b, "wakafetch_daily_seconds", "Coding time in seconds per day", dailySeconds)
+	writeMetric(&b, "wakafetch_up", "Whether the last refresh fetched everything", []metricSample{{nil, up}})
+	writeMetric(&b, "wakafetch_last_refresh_timestamp_seconds", "Unix time of the last refresh", []metricSample{{nil, float64(time.Now().Unix())}})
+	return []byte(b.String())
+}
+
+func writeMetric(b *strings.Builder, name, help string, samples []metricSample) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+	for _, s := range samples {
+		b.WriteString(name)
+		if len(s.labels) > 0 {
+			pairs := make([]string, len(s.labels))
+			for i, l := range s.labels {
+				pairs[i] = fmt.Sprintf(`%s="%s"`, l.name, escapeLabelValue(l.value))
+			}
+			b.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		b.WriteString(" " + strconv.FormatFloat(s.value, 'f', -1, 64) + "\n")
+	}
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
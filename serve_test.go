@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// wakapiStandIn answers /stats and /summaries with an hour of coding (on the first day asked for), or 503 while it's down
+type wakapiStandIn struct {
+	*httptest.Server
+	down     atomic.Bool
+	requests atomic.Int32
+}
+
+func newWakapiStandIn(t *testing.T) *wakapiStandIn {
+	t.Helper()
+	s := &wakapiStandIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if s.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch {
+		case strings.Contains(r.URL.Path, "/stats/"):
+			w.Write([]byte(`{"data":{"total_seconds":3600,"projects":[{"name":"wakafetch","total_seconds":3600}]}}`))
+		case strings.HasSuffix(r.URL.Path, "/summaries"):
+			date := r.URL.Query().Get("start")
+			w.Write([]byte(`{"data":[{"grand_total":{"total_seconds":3600},"range":{"date":"` + date + `","start":"` + date + `T00:00:00Z"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *wakapiStandIn) profile(name string) apiProfile {
+	return apiProfile{name: name, apiURL: s.URL + "/api", apiKey: "key-" + name}
+}
+
+// withServeSetup gives the test its own cache dir and serve's cache options, without retries
+func withServeSetup(t *testing.T, interval time.Duration) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	savedCache, savedRetries := cacheOpts, retryOpts
+	cacheOpts = cacheOptions{ttl: 5 * time.Minute}
+	retryOpts = retryPolicy{maxRetries: 0, maxWait: time.Second}
+	t.Cleanup(func() { cacheOpts, retryOpts = savedCache, savedRetries })
+	serveCacheOptions(interval)
+}
+
+func scrape(t *testing.T, h http.Handler) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestMetricsUnavailableBeforeFirstRefresh(t *testing.T) {
+	withServeSetup(t, time.Minute)
+	server := newWakapiStandIn(t)
+	metrics := &metricsHandler{}
+
+	if code, _ := scrape(t, metrics); code != http.StatusServiceUnavailable {
+		t.Fatalf("before the first refresh: got %d, want 503", code)
+	}
+	metrics.refresh([]apiProfile{server.profile("default")}, []string{"today"}, 1)
+	code, body := scrape(t, metrics)
+	if code != http.StatusOK || !strings.Contains(body, "wakafetch_up 1\n") {
+		t.Fatalf("after the first refresh: got %d\n%s", code, body)
+	}
+}
+
+func TestMetricsDownWhenServerUnreachable(t *testing.T) {
+	withServeSetup(t, time.Nanosecond)
+	server := newWakapiStandIn(t)
+	profiles := []apiProfile{server.profile("default")}
+
+	if body := string(collectMetrics(profiles, []string{"today"}, 1)); !strings.Contains(body, "wakafetch_up 1\n") {
+		t.Fatalf("server up:\n%s", body)
+	}
+	// the responses are cached now, serve mustn't fall back to them
+	server.down.Store(true)
+	if body := string(collectMetrics(profiles, []string{"today"}, 1)); !strings.Contains(body, "wakafetch_up 0\n") {
+		t.Fatalf("server down:\n%s", body)
+	}
+}
+
+func TestMetricsDownWhenOneProfileFails(t *testing.T) {
+	withServeSetup(t, time.Minute)
+	work, personal := newWakapiStandIn(t), newWakapiStandIn(t)
+	personal.down.Store(true)
+
+	body := string(collectMetrics([]apiProfile{work.profile("work"), personal.profile("personal")}, []string{"today"}, 1))
+	if !strings.Contains(body, "wakafetch_up 0\n") {
+		t.Fatalf("one profile down:\n%s", body)
+	}
+	if !strings.Contains(body, `wakafetch_range_seconds{range="today"} 3600`) {
+		t.Fatalf("the working profile's time is missing:\n%s", body)
+	}
+}
+
+func TestServeRefreshSkipsCache(t *testing.T) {
+	withServeSetup(t, 10*time.Millisecond)
+	if cacheOpts.ttl != 10*time.Millisecond {
+		t.Fatalf("ttl is %s, want the refresh interval", cacheOpts.ttl)
+	}
+	server := newWakapiStandIn(t)
+	profiles := []apiProfile{server.profile("default")}
+
+	collectMetrics(profiles, []string{"today"}, 1)
+	first := server.requests.Load()
+	time.Sleep(20 * time.Millisecond)
+	collectMetrics(profiles, []string{"today"}, 1)
+	if n := server.requests.Load(); n != 2*first {
+		t.Fatalf("second refresh made %d requests, want %d", n-first, first)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+func runTimeline(config Config) {
+	if len(config.args) > 0 {
+		ui.Errorln("Unknown argument: '%s'", config.args[0])
+	}
+	profiles := setupAPI(config)
+
+	today := truncateToDay(time.Now())
+	day := today
+	if *config.dateFlag != "" {
+		var err error
+		day, err = time.ParseInLocation(dateLayout, *config.dateFlag, time.Local)
+		if err != nil {
+			ui.Errorln("Invalid --date: '%s', expected YYYY-MM-DD", *config.dateFlag)
+		}
+		if day.After(today) {
+			ui.Errorln("--date is in the future (%s)", *config.dateFlag)
+		}
+	}
+
+	durations, err := fetchDurationsAll(profiles, day, day, "")
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+	ui.DisplayTimeline(durations, day)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+// `wakafetch tui` is always backed by /summaries, drilling into a project needs per project days
+
+var tuiRanges = []string{"today", "7d", "30d", "6m", "1y"}
+
+func runTUI(config Config) {
+	if len(config.args) > 0 {
+		ui.Errorln("Unknown argument: '%s'", config.args[0])
+	}
+	if stat, err := os.Stdout.Stat(); err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		ui.Errorln("wakafetch tui needs a terminal")
+	}
+	rangeIdx := -1
+	for i, r := range tuiRanges {
+		if r == *config.rangeFlag {
+			rangeIdx = i
+		}
+	}
+	if rangeIdx == -1 {
+		ui.Errorln("Invalid range for tui: '%s', must be one of today, 7d, 30d, 6m, 1y", *config.rangeFlag)
+	}
+	profiles := setupAPI(config)
+
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		ui.Errorln("Failed to open terminal: %s", err.Error())
+	}
+	defer tty.Close()
+
+	restore, err := ui.RawMode()
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		restore()
+		os.Exit(0)
+	}()
+
+	state := ui.TUIState{View: "stats"}
+	draw := func() { ui.DrawFrame(ui.RenderTUI(state), "") }
+
+	// load fetches the range (and project) into the state, a failure keeps the previous data on screen
+	load := func() bool {
+		state.Range = tuiRanges[rangeIdx]
+		state.Status = ui.Clr.Gray + "Loading..." + ui.Clr.Reset
+		draw()
+		dr, _ := rangeStrDateRange(getRangeStr(state.Range))
+		data, err := fetchSummaryAll(profiles, dr.start, dr.end, state.Project)
+		if err != nil {
+			state.Status = ui.Clr.Red + err.Error() + ui.Clr.Reset
+			return false
+		}
+		if state.Project != "" {
+			state.Payload = ui.ProjectPayload(data, state.Project, dr.label)
+			state.Heading = state.Project + ": " + dr.label
+		} else {
+			state.Payload = ui.SummaryPayload(data, true, dr.label)
+			state.Heading = dr.label
+		}
+		state.NoData = state.Payload == nil
+		state.Days = data.Data
+		state.Status = ""
+		return true
+	}
+	load()
+	draw()
+
+	// where to go back to after leaving a project
+	var projectsCard, projectsRow int
+
+	buf := make([]byte, 16)
+	for {
+		n, err := tty.Read(buf)
+		if err != nil {
+			break
+		}
+		cards := ui.TUICards(state.Payload, state.Project)
+		rows := 0
+		if state.Card < len(cards) {
+			rows = len(cards[state.Card].Items)
+		}
+
+		switch key := string(buf[:n]); key {
+		case "q", "Q":
+			restore()
+			return
+		case "\x1b[C", "l", "\t":
+			if len(cards) > 0 {
+				state.Card, state.Row = (state.Card+1)%len(cards), 0
+			}
+		case "\x1b[D", "h", "\x1b[Z":
+			if len(cards) > 0 {
+				state.Card, state.Row = (state.Card-1+len(cards))%len(cards), 0
+			}
+		case "\x1b[B", "j":
+			state.Row = min(state.Row+1, max(rows-1, 0))
+		case "\x1b[A", "k":
+			state.Row = max(state.Row-1, 0)
+		case "\n", "\r":
+			if state.Project == "" && state.Card < len(cards) && cards[state.Card].Title == "Projects" && rows > 0 {
+				state.Project = cards[state.Card].Items[state.Row].Name
+				// the payload only changes if the load worked, and the project has to match it
+				if load() {
+					projectsCard, projectsRow = state.Card, state.Row
+					state.Card, state.Row = 0, 0
+				} else {
+					state.Project = ""
+				}
+			}
+		case "\x1b", "\x7f", "\b":
+			if state.Project != "" {
+				project := state.Project
+				state.Project = ""
+				if !load() {
+					state.Project = project
+					break
+				}
+				// the overview may have changed since, with fewer cards or projects
+				cards := ui.TUICards(state.Payload, state.Project)
+				state.Card = min(projectsCard, max(len(cards)-1, 0))
+				state.Row = 0
+				if state.Card < len(cards) {
+					state.Row = min(projectsRow, max(len(cards[state.Card].Items)-1, 0))
+				}
+			}
+		case "1", "2", "3", "4", "5":
+			rangeIdx = int(key[0] - '1')
+			state.Row = 0
+			load()
+		case "s":
+			state.View = "stats"
+		case "d":
+			state.View = "daily"
+		case "m":
+			state.View = "heatmap"
+		}
+		draw()
+	}
+	restore()
+}
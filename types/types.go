@@ -3,8 +3,8 @@ package types
 type StatItem struct {
 	Name         string  `json:"name"`
 	TotalSeconds float64 `json:"total_seconds"`
+	Percent      float64 `json:"percent"`
 	// Seconds      float64 `json:"seconds"`
-	// Percent      float64 `json:"percent"`
 	// Digital      string  `json:"digital"`
 	// Hours        int     `json:"hours"`
 	// Minutes      int     `json:"minutes"`
@@ -61,6 +61,7 @@ type StatsResponse struct {
 	Data struct {
 		Branches                  []StatItem `json:"branches"`
 		Categories                []StatItem `json:"categories"`
+		Dependencies              []StatItem `json:"dependencies"`
 		Editors                   []StatItem `json:"editors"`
 		Languages                 []StatItem `json:"languages"`
 		Machines                  []StatItem `json:"machines"`
@@ -82,3 +83,17 @@ type StatsResponse struct {
 		IsOtherUsageVisible       bool       `json:"is_other_usage_visible"`
 	} `json:"data"`
 }
+
+// /durations, the coding sessions of a single day
+type DurationsResponse struct {
+	Data     []Duration `json:"data"`
+	Start    string     `json:"start"`
+	End      string     `json:"end"`
+	Timezone string     `json:"timezone"`
+}
+
+type Duration struct {
+	Project  string  `json:"project"`
+	Time     float64 `json:"time"` // unix timestamp
+	Duration float64 `json:"duration"`
+}
@@ -17,16 +17,7 @@ func dailyBreakdownStr(dailyData []types.DayData) ([]string, int) {
 
 	output := make([]string, 0, len(dailyData)+2)
 
-	// sort descending by date
-	sortedDays := make([]types.DayData, len(dailyData))
-	copy(sortedDays, dailyData)
-
-	sort.Slice(sortedDays, func(i, j int) bool {
-		dateI := strings.Split(sortedDays[i].Range.Start, "T")[0]
-		dateJ := strings.Split(sortedDays[j].Range.Start, "T")[0]
-		return dateI > dateJ
-	})
-
+	sortedDays := sortDaysDesc(dailyData)
 	maxSecs := findMaxDailySeconds(sortedDays)
 	cols := calculateDailyColumnWidths(sortedDays)
 
@@ -39,27 +30,48 @@ func dailyBreakdownStr(dailyData []types.DayData) ([]string, int) {
 	return output, cols.total
 }
 
+// sortDaysDesc returns a copy of the days, latest first
+func sortDaysDesc(dailyData []types.DayData) []types.DayData {
+	sortedDays := make([]types.DayData, len(dailyData))
+	copy(sortedDays, dailyData)
+
+	sort.Slice(sortedDays, func(i, j int) bool {
+		dateI := strings.Split(sortedDays[i].Range.Start, "T")[0]
+		dateJ := strings.Split(sortedDays[j].Range.Start, "T")[0]
+		return dateI > dateJ
+	})
+	return sortedDays
+}
+
 func dailyHeadersStr(cols dailyColumns) string {
 	headerDate := fmt.Sprintf("%-*s", cols.date, "Date")
 	headerTotal := fmt.Sprintf("%-*s", cols.time, "Time")
 	headerLang := fmt.Sprintf("%-*s", cols.lang, "Language")
 	headerProj := fmt.Sprintf("%-*s", cols.project, "Project")
 
-	return Clr.Blue + headerDate + Clr.Reset + " │ " + Clr.Blue + headerTotal + Clr.Reset + " │ " + Clr.Blue + headerLang + Clr.Reset + " │ " + Clr.Blue + headerProj + Clr.Reset
+	header := Clr.Blue + headerDate + Clr.Reset + " │ " + Clr.Blue + headerTotal + Clr.Reset + " │ " + Clr.Blue + headerLang + Clr.Reset + " │ " + Clr.Blue + headerProj + Clr.Reset
+	for i, g := range dayGoals {
+		header += " │ " + Clr.Blue + fmt.Sprintf("%-*s", cols.goals[i], g.Name) + Clr.Reset
+	}
+	return header
 }
 
 func dailySeparatorStr(cols dailyColumns) string {
-	return strings.Repeat("─", cols.date) + "─┼─" +
+	separator := strings.Repeat("─", cols.date) + "─┼─" +
 		strings.Repeat("─", cols.time) + "─┼─" +
 		strings.Repeat("─", cols.lang) + "─┼─" +
 		strings.Repeat("─", cols.project)
+	for _, width := range cols.goals {
+		separator += "─┼─" + strings.Repeat("─", width)
+	}
+	return separator
 }
 
 func dailyRowsStr(dailyData []types.DayData, cols dailyColumns, maxSecs float64) []string {
 	output := make([]string, 0, len(dailyData))
 
 	for _, day := range dailyData {
-		if day.GrandTotal.TotalSeconds < 60 {
+		if !IsActiveDay(day) {
 			continue
 		}
 
@@ -78,6 +90,13 @@ func dailyRowsStr(dailyData []types.DayData, cols dailyColumns, maxSecs float64)
 		topProj := fmt.Sprintf("%-*s", cols.project, topItemName(day.Projects, true))
 
 		row := date + " │ " + Clr.Green + totalFormatted + Clr.Reset + " │ " + topLang + " │ " + topProj
+		for i, g := range dayGoals {
+			mark := Clr.Red + "✗" + Clr.Reset
+			if g.Met(day) {
+				mark = Clr.Green + "✓" + Clr.Reset
+			}
+			row += " │ " + mark + strings.Repeat(" ", cols.goals[i]-1)
+		}
 		output = append(output, row)
 	}
 
@@ -99,6 +118,7 @@ type dailyColumns struct {
 	time    int
 	lang    int
 	project int
+	goals   []int // pass/fail column per daily goal
 	total   int
 }
 
@@ -114,7 +134,7 @@ func calculateDailyColumnWidths(dailyData []types.DayData) dailyColumns {
 	maxSecs := findMaxDailySeconds(dailyData)
 
 	for _, day := range dailyData {
-		if day.GrandTotal.TotalSeconds < 60 {
+		if !IsActiveDay(day) {
 			continue
 		}
 
@@ -140,5 +160,9 @@ func calculateDailyColumnWidths(dailyData []types.DayData) dailyColumns {
 	// spaces for bar
 	cols.time += maxTableBarWidth + 1
 	cols.total = cols.date + 3 + cols.time + 3 + cols.lang + 3 + cols.project
+	for _, g := range dayGoals {
+		cols.goals = append(cols.goals, len(g.Name))
+		cols.total += 3 + len(g.Name)
+	}
 	return cols
 }
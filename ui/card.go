@@ -1,6 +1,9 @@
 package ui
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
+)
 
 func cardify(content []string, header string, contentWidth int, rightPad int) ([]string, int) {
 	var (
@@ -19,11 +22,13 @@ func cardify(content []string, header string, contentWidth int, rightPad int) ([
 	cardWidth := contentWidth + 4
 
 	// if header is longer than content, adjust card width
-	if len(header) > contentWidth {
-		cardWidth = len(header) + 4
+	// headings can have project names, count runes not bytes
+	headerWidth := utf8.RuneCountInString(header)
+	if headerWidth > contentWidth {
+		cardWidth = headerWidth + 4
 	}
 
-	availableSpace := max(0, cardWidth-len(header)-2) // -2 for corner chars
+	availableSpace := max(0, cardWidth-headerWidth-2) // -2 for corner chars
 
 	leftPadding := availableSpace / 2
 	rightPadding := availableSpace - leftPadding
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// with --compare, the stats and every bar get the change since the previous period of the same length next to them
+
+// DisplayComparison renders p with the deltas against prev, which is nil when the previous period had no data
+func DisplayComparison(p, prev *DisplayPayload, prevRange string) {
+	if p == nil {
+		Warnln("No data available for the selected period")
+		return
+	}
+	if prev == nil {
+		prev = &DisplayPayload{}
+	}
+	p.compareWith(prev)
+	p.Stats = append(p.Stats, Field{Key: "Compared To", Val: prevRange})
+	render(p)
+}
+
+func (p *DisplayPayload) compareWith(prev *DisplayPayload) {
+	p.deltas = map[string]string{
+		"Total Time": timeDelta(p.totalSeconds, prev.totalSeconds),
+		"Daily Avg":  timeDelta(p.dailyAvgSeconds, prev.dailyAvgSeconds),
+	}
+	if d := p.activeDays - prev.activeDays; d != 0 {
+		p.deltas["Active Days"] = fmt.Sprintf("%s %s%d", deltaArrow(float64(d)), deltaSign(float64(d)), max(d, -d))
+	}
+
+	p.prev = make(map[string]map[string]float64)
+	for _, list := range prev.lists() {
+		seconds := make(map[string]float64)
+		for _, item := range list.items {
+			seconds[item.Name] = item.TotalSeconds
+		}
+		p.prev[list.title] = seconds
+	}
+}
+
+// timeDelta is like "▲ +2h 10m (+35%)", empty when nothing changed
+func timeDelta(cur, prev float64) string {
+	diff := cur - prev
+	if math.Abs(diff) < 60 {
+		return ""
+	}
+	sign := deltaSign(diff)
+	s := deltaArrow(diff) + " " + sign + shortTimeFmt(math.Abs(diff))
+	if prev == 0 {
+		return s + " (new)"
+	}
+	return s + fmt.Sprintf(" (%s%.0f%%)", sign, math.Abs(diff)/prev*100)
+}
+
+func deltaArrow(diff float64) string {
+	if diff < 0 {
+		return "▼"
+	}
+	return "▲"
+}
+
+// deltaSign uses a real minus sign, it lines up with the plus
+func deltaSign(diff float64) string {
+	if diff < 0 {
+		return "−"
+	}
+	return "+"
+}
+
+// shortTimeFmt leaves out the seconds (and zero minutes), deltas and targets don't need them
+func shortTimeFmt(seconds float64) string {
+	sec := int(seconds)
+	if sec < 3600 {
+		return fmt.Sprintf("%dm", sec/60)
+	}
+	if sec%3600 < 60 {
+		return fmt.Sprintf("%dh", sec/3600)
+	}
+	return fmt.Sprintf("%dh %dm", sec/3600, (sec%3600)/60)
+}
+
+// colorDelta pads the delta to width and colors it by its arrow
+func colorDelta(delta string, width int) string {
+	padded := delta + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(delta)))
+	switch {
+	case strings.HasPrefix(delta, "▲"):
+		return Clr.Green + padded + Clr.Reset
+	case strings.HasPrefix(delta, "▼"):
+		return Clr.Red + padded + Clr.Reset
+	}
+	return padded
+}
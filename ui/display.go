@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/sahaj-b/wakafetch/types"
@@ -15,6 +16,7 @@ type Field struct {
 type DisplayPayload struct {
 	Heading          string
 	Stats            []Field
+	Streaks          []Field // only from /summaries, which has the days
 	Languages        []types.StatItem
 	Editors          []types.StatItem
 	Projects         []types.StatItem
@@ -22,14 +24,67 @@ type DisplayPayload struct {
 	Categories       []types.StatItem
 	Machines         []types.StatItem
 	Entities         []types.StatItem
+	Branches         []types.StatItem
+	Dependencies     []types.StatItem
+	Weekdays         []types.StatItem // average per weekday, monday first. Only from /summaries
+	Hours            []float64        // seconds per hour of the day, only with --hours
+	Project          string           // set for a single project's payload, which gets its own set of cards
 	Full             bool
+
+	// for --compare, set by SummaryPayload
+	totalSeconds    float64
+	dailyAvgSeconds float64
+	activeDays      int
+	deltas          map[string]string             // stats key -> change since the previous period
+	prev            map[string]map[string]float64 // card title -> name -> seconds in the previous period
+}
+
+type statList struct {
+	title string
+	items []types.StatItem
+	limit int
+}
+
+// lists are the cards of a payload in display order, for the outputs that aren't laid out in columns (html, markdown)
+func (p *DisplayPayload) lists() []statList {
+	if p.Project != "" {
+		return []statList{
+			{"Languages", p.Languages, 0},
+			{"Branches", p.Branches, 0},
+			{"Entities", p.Entities, 10},
+			{"Editors", p.Editors, 0},
+			{"Categories", p.Categories, 0},
+			{weekdayTitle, p.Weekdays, 0},
+		}
+	}
+	return []statList{
+		{"Languages", p.Languages, 0},
+		{"Projects", p.Projects, 0},
+		{"Editors", p.Editors, 0},
+		{"Categories", p.Categories, 0},
+		{"Operating Systems", p.OperatingSystems, 0},
+		{"Machines", p.Machines, 0},
+		{"Entities", p.Entities, 5},
+		{"Branches", p.Branches, 5},
+		{"Dependencies", p.Dependencies, 5},
+		{weekdayTitle, p.Weekdays, 0},
+	}
 }
 
 func DisplayStats(data *types.StatsResponse, full bool, rangeStr string) {
-	if data == nil || (data.Data.TotalSeconds == 0 && len(data.Data.Languages) == 0 && len(data.Data.Projects) == 0) {
+	payload := StatsPayload(data, full, rangeStr)
+	if payload == nil {
 		Warnln("No data available for the selected period: '%s'", rangeStr)
 		return
 	}
+	render(payload)
+}
+
+// StatsPayload builds the cards for a /stats response, nil if there's no data
+func StatsPayload(data *types.StatsResponse, full bool, rangeStr string) *DisplayPayload {
+	if data == nil || (data.Data.TotalSeconds == 0 && len(data.Data.Languages) == 0 && len(data.Data.Projects) == 0) {
+		return nil
+	}
 
 	stats := data.Data
 	var heading string
@@ -78,9 +133,11 @@ func DisplayStats(data *types.StatsResponse, full bool, rangeStr string) {
 		Categories:       stats.Categories,
 		Machines:         stats.Machines,
 		Entities:         nil, // stats response doesn't have entities
+		Branches:         stats.Branches,
+		Dependencies:     stats.Dependencies,
 		Full:             full,
 	}
-	render(&payload)
+	return &payload
 }
 
 type job struct {
@@ -88,16 +145,28 @@ type job struct {
 	getter    func(types.DayData) []types.StatItem
 }
 
-func DisplaySummary(data *types.SummaryResponse, full bool, rangeStr string) {
-	if data == nil || len(data.Data) == 0 {
+// DisplaySummary renders the days, hours are the seconds per hour of the day for the By Hour card (nil for none)
+func DisplaySummary(data *types.SummaryResponse, full bool, rangeStr string, hours []float64) {
+	payload := SummaryPayload(data, full, rangeStr)
+	if payload == nil {
 		Warnln("No data available for the selected period: '%s'", rangeStr)
 		return
 	}
+	payload.Hours = hours
+	render(payload)
+}
+
+// SummaryPayload aggregates the days of a /summaries response into cards, nil if there's no data
+func SummaryPayload(data *types.SummaryResponse, full bool, rangeStr string) *DisplayPayload {
+	if data == nil || len(data.Data) == 0 {
+		return nil
+	}
 
 	languages := make(map[string]float64)
 
 	// Only process additional data if full mode is on
 	projects, editors, operatingSystems, categories, machines, entities := make(map[string]float64), make(map[string]float64), make(map[string]float64), make(map[string]float64), make(map[string]float64), make(map[string]float64)
+	branches, dependencies := make(map[string]float64), make(map[string]float64)
 
 	aggregateJobs := []job{
 		{languages, func(day types.DayData) []types.StatItem { return day.Languages }},
@@ -107,6 +176,8 @@ func DisplaySummary(data *types.SummaryResponse, full bool, rangeStr string) {
 		{categories, func(day types.DayData) []types.StatItem { return day.Categories }},
 		{machines, func(day types.DayData) []types.StatItem { return day.Machines }},
 		{entities, func(day types.DayData) []types.StatItem { return day.Entities }},
+		{branches, func(day types.DayData) []types.StatItem { return day.Branches }},
+		{dependencies, func(day types.DayData) []types.StatItem { return day.Dependencies }},
 	}
 
 	processJobs(data.Data, aggregateJobs)
@@ -162,6 +233,11 @@ func DisplaySummary(data *types.SummaryResponse, full bool, rangeStr string) {
 		Field{"Projects", numProjects},
 	)
 
+	var streaks []Field
+	if len(data.Data) > 1 {
+		streaks = streakFields(data.Data)
+	}
+
 	payload := &DisplayPayload{
 		Heading:          heading,
 		Stats:            statsMap,
@@ -172,20 +248,65 @@ func DisplaySummary(data *types.SummaryResponse, full bool, rangeStr string) {
 		Full:             full,
 		Categories:       aggregatedCategories,
 		Machines:         aggregatedMachines,
+		Entities:         mapToSortedStatItems(entities),
+		Branches:         mapToSortedStatItems(branches),
+		Dependencies:     mapToSortedStatItems(dependencies),
+		Streaks:          streaks,
+		Weekdays:         weekdayItems(data.Data),
+		totalSeconds:     data.CumulativeTotal.Seconds,
+		dailyAvgSeconds:  data.DailyAverage.Seconds,
+		activeDays:       data.DailyAverage.DaysMinusHolidays,
+	}
+	return payload
+}
+
+func DisplayProject(data *types.SummaryResponse, project, rangeStr string, hours []float64) {
+	payload := ProjectPayload(data, project, rangeStr)
+	if payload == nil {
+		Warnln("No data available for project '%s' in the selected period: '%s'", project, rangeStr)
+		return
 	}
+	payload.Hours = hours
 	render(payload)
 }
 
+// ProjectPayload is SummaryPayload for summaries fetched with a project filter: the cards and stats are about
+// what went into the project, the projects card would only list the project itself
+func ProjectPayload(data *types.SummaryResponse, project, rangeStr string) *DisplayPayload {
+	p := SummaryPayload(data, true, rangeStr)
+	if p == nil || data.CumulativeTotal.Seconds == 0 {
+		return nil
+	}
+	p.Project = project
+	p.Heading = project + ": " + p.Heading
+
+	// keep the time fields, they come before the tops
+	stats := p.Stats[:slices.IndexFunc(p.Stats, func(f Field) bool { return f.Key == "Top Project" })]
+	p.Stats = append(stats,
+		Field{"Top Language", topItemName(p.Languages, false)},
+		Field{"Top Branch", topItemName(p.Branches, false)},
+		Field{"Top Editor", topItemName(p.Editors, false)},
+		Field{"Files", fmt.Sprintf("%d", len(p.Entities))},
+	)
+	return p
+}
+
 func DisplayBreakdown(data []types.DayData, heading string) {
 	if len(data) == 0 {
 		Warnln("No daily data available")
 		return
 	}
+	if markdownOutput {
+		markdownBreakdown(displayOut, data, heading)
+		return
+	}
 	dailyTable, tableWidth := dailyBreakdownStr(data)
 	cardTable, _ := cardify(dailyTable, heading, tableWidth, 0)
 	printStrs(cardTable)
-	if Clr.Blue == "" {
-		return // dont display heatmap if colors disabled
+	if len(data) > 1 {
+		streakLines, streakWidth := streaksStr(streakFields(data))
+		cardStreaks, _ := cardify(streakLines, "Streaks", streakWidth, 0)
+		printStrs(cardStreaks)
 	}
 	heatmapStrs, heatmapWidth := heatmap(data)
 	cardHeatmap, _ := cardify(heatmapStrs, "Heatmap", heatmapWidth, 0)
@@ -197,6 +318,11 @@ func DisplayHeatmap(data []types.DayData, heading string) {
 		Warnln("No daily data available")
 		return
 	}
+	// no grid in markdown, the table has the same days
+	if markdownOutput {
+		markdownBreakdown(displayOut, data, heading)
+		return
+	}
 
 	heatmapStrs, heatmapWidth := heatmap(data)
 	if len(heatmapStrs) == 0 {
@@ -224,8 +350,12 @@ func mapToSortedStatItems(m map[string]float64) []types.StatItem {
 	for name, seconds := range m {
 		items = append(items, types.StatItem{Name: name, TotalSeconds: seconds})
 	}
+	// ties by name, map order would shuffle them between runs
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].TotalSeconds > items[j].TotalSeconds
+		if items[i].TotalSeconds != items[j].TotalSeconds {
+			return items[i].TotalSeconds > items[j].TotalSeconds
+		}
+		return items[i].Name < items[j].Name
 	})
 	return items
 }
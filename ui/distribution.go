@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// when in the week and in the day the coding happens: By Weekday averages from the days, By Hour from /durations
+
+const hourRows = 4
+
+// weekdayTitle says the values are averages, a weekday's total would depend on how often it's in the range
+const weekdayTitle = "By Weekday (avg)"
+
+var hourBlocks = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+// weekdayItems averages the days per weekday, monday first. nil for a week or less, there'd be nothing to average
+func weekdayItems(days []types.DayData) []types.StatItem {
+	if len(days) <= 7 {
+		return nil
+	}
+	var totals [7]float64
+	var counts [7]int
+	for _, day := range days {
+		dateStr := day.Range.Date
+		if dateStr == "" {
+			dateStr = strings.Split(day.Range.Start, "T")[0]
+		}
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			continue
+		}
+		i := (int(date.Weekday()) + 6) % 7
+		totals[i] += day.GrandTotal.TotalSeconds
+		counts[i]++
+	}
+	items := make([]types.StatItem, 0, 7)
+	for i, total := range totals {
+		name := time.Weekday((i + 1) % 7).String()[:3]
+		avg := 0.0
+		if counts[i] > 0 {
+			avg = total / float64(counts[i])
+		}
+		items = append(items, types.StatItem{Name: name, TotalSeconds: avg})
+	}
+	return items
+}
+
+// HourTotals spreads the sessions over the hours of the day (local time), a session crossing an hour counts in both
+func HourTotals(durations []types.Duration) []float64 {
+	if len(durations) == 0 {
+		return nil
+	}
+	hours := make([]float64, 24)
+	for _, d := range durations {
+		start := time.Unix(0, int64(d.Time*float64(time.Second))).Local()
+		end := start.Add(time.Duration(d.Duration * float64(time.Second)))
+		for t := start; t.Before(end); {
+			// not t.Truncate(time.Hour), that rounds in UTC and is off for half hour timezones
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			if next.After(end) {
+				next = end
+			}
+			hours[t.Hour()] += next.Sub(t).Seconds()
+			t = next
+		}
+	}
+	return hours
+}
+
+// hoursStr draws the hours as columns, with the peak hour and the share of late night (22 to 6) coding under them
+func hoursStr(hours []float64) ([]string, int) {
+	maxSeconds, total, night := 0.0, 0.0, 0.0
+	peak := 0
+	for h, secs := range hours {
+		total += secs
+		if h >= 22 || h < 6 {
+			night += secs
+		}
+		if secs > maxSeconds {
+			maxSeconds, peak = secs, h
+		}
+	}
+	if maxSeconds < 60 {
+		return []string{}, 0
+	}
+
+	output := make([]string, 0, hourRows+2)
+	for row := range hourRows {
+		line := ""
+		for _, secs := range hours {
+			eighths := int(secs / maxSeconds * hourRows * 8)
+			if eighths == 0 && secs >= 60 {
+				eighths = 1
+			}
+			level := min(8, max(0, eighths-(hourRows-1-row)*8))
+			line += hourBlocks[level] + " "
+		}
+		output = append(output, Clr.Green+line+Clr.Reset)
+	}
+	width := len(hours) * 2
+	axis := ""
+	for h := 0; h < len(hours); h += 6 {
+		axis += fmt.Sprintf("%-12s", fmt.Sprintf("%02d", h))
+	}
+	output = append(output, Clr.Gray+fmt.Sprintf("%-*s", width, axis)+Clr.Reset)
+
+	peakStr := fmt.Sprintf("%02d:00", peak)
+	nightStr := fmt.Sprintf("%.0f%%", night/total*100)
+	footer := "Peak  " + peakStr + "   Late Night  " + nightStr
+	width = max(width, len(footer))
+	output = append(output, Clr.BoldBlue+"Peak  "+Clr.Reset+peakStr+"   "+Clr.BoldBlue+"Late Night  "+Clr.Reset+nightStr+strings.Repeat(" ", width-len(footer)))
+	return output, width
+}
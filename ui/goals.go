@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// GoalProgress is a goal evaluated against the fetched days, the goals themselves are parsed in main
+type GoalProgress struct {
+	Name   string
+	Spec   string
+	Done   float64 // seconds (or active days) in the current period
+	Target float64
+	Days   bool // Done and Target count days
+	Met    bool
+	Period string // day or week
+	Streak int    // periods in a row meeting the goal, up to the current one
+	Hit    int    // periods meeting the goal
+	Total  int    // periods so far, the current one only once it's met
+}
+
+// DayGoal is a per day goal, shown as a pass/fail column in the daily breakdown
+type DayGoal struct {
+	Name string
+	Met  func(types.DayData) bool
+}
+
+var dayGoals []DayGoal
+
+func SetDayGoals(goals []DayGoal) {
+	dayGoals = goals
+}
+
+const goalBarWidth = 20
+
+func DisplayGoals(goals []GoalProgress, heading string) {
+	lines, width := goalsStr(goals)
+	card, _ := cardify(lines, heading, width, 0)
+	printStrs(card)
+}
+
+func goalsStr(goals []GoalProgress) ([]string, int) {
+	// name, spec, progress, percent, streak, record
+	cells := make([][6]string, len(goals))
+	var widths [6]int
+	for i, g := range goals {
+		unit := "days"
+		if g.Period == "week" {
+			unit = "weeks"
+		}
+		progress := fmt.Sprintf("%s / %s", shortTimeFmt(g.Done), shortTimeFmt(g.Target))
+		if g.Days {
+			progress = fmt.Sprintf("%d/%d days", int(g.Done), int(g.Target))
+		}
+		cells[i] = [6]string{
+			g.Name,
+			g.Spec,
+			progress,
+			fmt.Sprintf("%.0f%%", g.Done/g.Target*100),
+			fmt.Sprintf("streak %d", g.Streak),
+			fmt.Sprintf("%d/%d %s", g.Hit, g.Total, unit),
+		}
+		for j, cell := range cells[i] {
+			widths[j] = max(widths[j], len(cell))
+		}
+	}
+
+	output := make([]string, 0, len(goals))
+	for i, g := range goals {
+		c := cells[i]
+		mark := Clr.Yellow + "·" + Clr.Reset
+		if g.Met {
+			mark = Clr.Green + "✓" + Clr.Reset
+		}
+		filled := min(goalBarWidth, int(g.Done/g.Target*goalBarWidth))
+		rest := strings.Repeat(barChar, goalBarWidth-filled)
+		if Clr.Gray == "" {
+			rest = strings.Repeat(" ", goalBarWidth-filled)
+		}
+		line := mark + " " +
+			Clr.BoldBlue + fmt.Sprintf("%-*s", widths[0], c[0]) + Clr.Reset + " " +
+			Clr.Gray + fmt.Sprintf("%-*s", widths[1], c[1]) + Clr.Reset + "  " +
+			Clr.Green + strings.Repeat(barChar, filled) + Clr.Reset + Clr.Gray + rest + Clr.Reset + " " +
+			fmt.Sprintf("%-*s %*s  %-*s  ", widths[2], c[2], widths[3], c[3], widths[4], c[4]) +
+			Clr.Gray + fmt.Sprintf("%-*s", widths[5], c[5]) + Clr.Reset
+		output = append(output, line)
+	}
+	width := 2 + widths[0] + 1 + widths[1] + 2 + goalBarWidth + 1 + widths[2] + 1 + widths[3] + 2 + widths[4] + 2 + widths[5]
+	return output, width
+}
@@ -3,11 +3,14 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/sahaj-b/wakafetch/types"
 )
 
-func graphStr(items []types.StatItem, limit int) ([]string, int) {
+// graphStr draws a bar per item, relative to the longest one since items aren't always sorted (weekdays).
+// With prev (seconds per name in the previous period, for --compare) each bar gets its delta
+func graphStr(items []types.StatItem, limit int, prev map[string]float64) ([]string, int) {
 	if len(items) == 0 {
 		return []string{}, 0
 	}
@@ -17,23 +20,26 @@ func graphStr(items []types.StatItem, limit int) ([]string, int) {
 	}
 	visibleItems := items[:count]
 
-	if len(visibleItems) == 0 || visibleItems[0].TotalSeconds == 0 {
-		return []string{}, 0
-	}
-
 	output := make([]string, 0, len(visibleItems))
 
 	maxNameLength := 0
 	maxSeconds := 0.0
+	maxDeltaLength := 0
 	for _, item := range visibleItems {
 		maxNameLength = max(maxNameLength, len(item.Name))
 		maxSeconds = max(maxSeconds, item.TotalSeconds)
+		if prev != nil {
+			maxDeltaLength = max(maxDeltaLength, utf8.RuneCountInString(timeDelta(item.TotalSeconds, prev[item.Name])))
+		}
+	}
+	if maxSeconds == 0 {
+		return []string{}, 0
 	}
 	for _, item := range visibleItems {
 		if item.TotalSeconds < 60 {
 			continue
 		}
-		barLength := int((item.TotalSeconds / visibleItems[0].TotalSeconds) * float64(barWidth))
+		barLength := int((item.TotalSeconds / maxSeconds) * float64(barWidth))
 		secondBarLength := barWidth - barLength
 		if barLength < 1 {
 			barLength = 1
@@ -50,8 +56,14 @@ func graphStr(items []types.StatItem, limit int) ([]string, int) {
 			Clr.Green + bar + Clr.Reset +
 			Clr.Gray + secondBar + Clr.Reset + " " +
 			Clr.Green + timeFmtPad(item.TotalSeconds, maxSeconds) + Clr.Reset
+		if maxDeltaLength > 0 {
+			line += " " + colorDelta(timeDelta(item.TotalSeconds, prev[item.Name]), maxDeltaLength)
+		}
 		output = append(output, line)
 	}
 	graphWidth := maxNameLength + 1 + barWidth + 1 + len(timeFmtPad(maxSeconds, maxSeconds))
+	if maxDeltaLength > 0 {
+		graphWidth += 1 + maxDeltaLength
+	}
 	return output, graphWidth
 }
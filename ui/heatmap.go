@@ -2,69 +2,196 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/sahaj-b/wakafetch/types"
 )
 
+const heatmapChar = "■" // █ ❐ ▪ ◼ 🙩 🙫 ⛝ ⏹ 🞕 🞔 🞖
+
+// heatmapLevels are the shades of the terminal heatmap, 0 being no activity
+const heatmapLevels = 5
+
+// heatmap draws the days as a calendar, a column per week with Monday on top, month and weekday labels
+// and a legend. Narrow terminals get the most recent weeks that fit
 func heatmap(days []types.DayData) ([]string, int) {
-	const heatmapChar = "■"               // █ ❐ ▪ ◼ 🙩 🙫 ⛝ ⏹ 🞕 🞔 🞖
-	const highlight = "\x1b[38;2;0;%v;0m" // \x1b[38;2;R;G;Bm
-	if len(days) == 0 {
+	const gutter = 4 // "Mon "
+	weeks := calendarGrid(days)
+	if len(weeks) == 0 {
 		return []string{}, 0
 	}
-	maxSecs := 0
-	startDay, err := time.Parse("2006-01-02", strings.Split(days[0].Range.Start, "T")[0])
-	if err != nil {
-		return []string{}, 0
+	if fit := (getTerminalCols() - 4 - gutter + 1) / 2; fit > 0 && len(weeks) > fit {
+		weeks = weeks[len(weeks)-fit:]
 	}
-	endDay, err := time.Parse("2006-01-02", strings.Split(days[len(days)-1].Range.Start, "T")[0])
-	if err != nil {
-		return []string{}, 0
+	thresholds := levelThresholds(weeks)
+
+	gridWidth := gutter + 2*len(weeks) - 1
+	monthRow := []byte(strings.Repeat(" ", gridWidth+3))
+	for col, label := range monthLabels(weeks) {
+		copy(monthRow[gutter+2*col:], label)
 	}
+	monthLine := strings.TrimRight(string(monthRow), " ")
+	legend, legendWidth := heatmapLegend(thresholds)
+	width := max(gridWidth, len(monthLine), legendWidth)
 
-	height := 4
-	numOfDays := int(endDay.Sub(startDay).Hours()/24) + 1
-	cols := getTerminalCols()
-	width := 2*int((numOfDays+height-1)/height) - 1 // 2*ciel -1
-	for width+4 > cols {
-		height++
-		width = 2*int((numOfDays+height-1)/height) - 1
+	output := []string{Clr.Gray + fmt.Sprintf("%-*s", width, monthLine) + Clr.Reset}
+	for row, name := range []string{"Mon", "", "Wed", "", "Fri", "", "Sun"} {
+		line := Clr.Gray + fmt.Sprintf("%-*s", gutter, name) + Clr.Reset
+		for col, week := range weeks {
+			if col > 0 {
+				line += " "
+			}
+			if week[row] == nil {
+				line += " "
+				continue
+			}
+			line += heatmapCell(heatmapLevel(week[row].seconds, thresholds))
+		}
+		output = append(output, line+strings.Repeat(" ", width-gridWidth))
 	}
+	output = append(output, strings.Repeat(" ", width), legend+strings.Repeat(" ", width-legendWidth))
+	return output, width
+}
 
-	for _, day := range days {
-		if int(day.GrandTotal.TotalSeconds) > maxSecs {
-			maxSecs = int(day.GrandTotal.TotalSeconds)
+// idleThresholds is the scale for a range without any activity, there are no quartiles to go by
+var idleThresholds = [heatmapLevels - 1]float64{1, 3600, 2 * 3600, 4 * 3600}
+
+// levelThresholds are the least seconds for levels 1 to 4: the configured ones,
+// or any activity and the quartiles of the active days, so one long day doesn't wash out the rest
+func levelThresholds(weeks [][7]*heatmapDay) [heatmapLevels - 1]float64 {
+	var t [heatmapLevels - 1]float64
+	if heatmapThresholds != nil {
+		copy(t[:], heatmapThresholds)
+		return t
+	}
+	var active []float64
+	for _, week := range weeks {
+		for _, day := range week {
+			if day != nil && day.seconds > 0 {
+				active = append(active, day.seconds)
+			}
 		}
 	}
-	output := make([]string, height)
-	dataIndex := 0
-	i := 0
-	for d := startDay; !d.After(endDay); d = d.AddDate(0, 0, 1) {
-		strength255 := 0
-		if dataIndex < len(days) && strings.Split(days[dataIndex].Range.Start, "T")[0] == d.Format("2006-01-02") {
-			day := days[dataIndex]
-			dataIndex++
-			strength255 = int(255 * day.GrandTotal.TotalSeconds / float64(maxSecs))
+	if len(active) == 0 {
+		return idleThresholds
+	}
+	slices.Sort(active)
+	t[0] = 1
+	for i := 1; i < len(t); i++ {
+		t[i] = max(1, active[len(active)*i/len(t)])
+	}
+	return t
+}
+
+func heatmapLevel(seconds float64, thresholds [heatmapLevels - 1]float64) int {
+	level := 0
+	for _, t := range thresholds {
+		if seconds >= t {
+			level++
 		}
-		char := fmt.Sprintf(highlight, strength255) + heatmapChar + "\x1b[0m"
-		output[i%height] += char + " "
-		i++
 	}
-	// trim trailing spaces
-	for j := range output {
-		output[j] = strings.TrimRight(output[j], " ")
+	return level
+}
+
+// heatmapGlyphs stand in for the shades when colors are off
+var heatmapGlyphs = [heatmapLevels]string{"·", "░", "▒", "▓", "█"}
+
+func heatmapCell(level int) string {
+	if Clr.Reset == "" {
+		return heatmapGlyphs[level]
 	}
+	return shadeEscape(level) + heatmapChar + "\x1b[0m"
+}
 
-	// ensure same width for all lines
-	for i%height != 0 {
-		if i/height < 1 {
-			output[i%height] += " "
-		} else {
-			output[i%height] += "  "
+// heatmapLegend is "Less ■ 0 ■ <1h ■ 1h+ ... More", every shade with the time it starts at
+func heatmapLegend(thresholds [heatmapLevels - 1]float64) (string, int) {
+	legend := Clr.Gray + "Less" + Clr.Reset
+	width := len("Less")
+	for level := range heatmapLevels {
+		var label string
+		switch {
+		case level == 0 && thresholds[0] < 60:
+			label = "0"
+		case level == 0:
+			label = "<" + shortTimeFmt(thresholds[0])
+		case thresholds[level-1] < 60 && level < len(thresholds):
+			// "<0m" for sub-minute days says nothing
+			label = "<" + shortTimeFmt(max(60, thresholds[level]))
+		default:
+			label = shortTimeFmt(thresholds[level-1]) + "+"
 		}
-		i++
+		legend += " " + heatmapCell(level) + " " + Clr.Gray + label + Clr.Reset
+		width += 3 + len(label)
 	}
-	return output, width
+	return legend + " " + Clr.Gray + "More" + Clr.Reset, width + len(" More")
+}
+
+// heatmapDay is a cell of the calendar grid
+type heatmapDay struct {
+	date    time.Time
+	seconds float64
+}
+
+// calendarGrid lays the days out like a calendar: one column per week, Monday on top.
+// Cells before the first day and after the last one are nil, days without data have 0 seconds
+func calendarGrid(days []types.DayData) [][7]*heatmapDay {
+	secsByDate := make(map[string]float64)
+	var first, last time.Time
+	for _, day := range days {
+		date, err := time.Parse("2006-01-02", strings.Split(day.Range.Start, "T")[0])
+		if err != nil {
+			continue
+		}
+		secsByDate[date.Format("2006-01-02")] += day.GrandTotal.TotalSeconds
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if date.After(last) {
+			last = date
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+
+	var weeks [][7]*heatmapDay
+	var week [7]*heatmapDay
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		row := weekdayRow(d)
+		if row == 0 && !d.Equal(first) {
+			weeks = append(weeks, week)
+			week = [7]*heatmapDay{}
+		}
+		week[row] = &heatmapDay{date: d, seconds: secsByDate[d.Format("2006-01-02")]}
+	}
+	return append(weeks, week)
+}
+
+// weekdayRow is the row of a day in the calendar grid, 0 for Monday
+func weekdayRow(d time.Time) int {
+	return (int(d.Weekday()) + 6) % 7
+}
+
+// monthLabels maps week columns to the month starting in them. A label too close to the next one
+// (a month starting at the end of the range) is dropped, so they don't overlap
+func monthLabels(weeks [][7]*heatmapDay) map[int]string {
+	const minGap = 2
+	labels := make(map[int]string)
+	lastMonth, lastCol := time.Month(0), -minGap
+	for col, week := range weeks {
+		for _, day := range week {
+			if day == nil || day.date.Month() == lastMonth {
+				continue
+			}
+			if col-lastCol < minGap {
+				delete(labels, lastCol)
+			}
+			labels[col] = day.date.Format("Jan")
+			lastMonth, lastCol = day.date.Month(), col
+			break
+		}
+	}
+	return labels
 }
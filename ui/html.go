@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// the HTML report is a single file with inline CSS and SVG, so it can be attached to emails as is.
+// It shows the same cards as render(), with real text instead of a terminal screenshot
+
+type htmlCard struct {
+	Title string
+	Rows  []htmlBar
+}
+
+type htmlBar struct {
+	Name    string
+	Time    string
+	Percent float64 // bar length, relative to the top item
+}
+
+type htmlReport struct {
+	Heading   string
+	Generated string
+	Stats     []Field
+	Cards     []htmlCard
+	Heatmap   template.HTML
+}
+
+// WriteHTML renders the payload as an HTML report. days are only used for the heatmap, and can be nil
+func WriteHTML(w io.Writer, p *DisplayPayload, days []types.DayData) error {
+	report := htmlReport{
+		Heading:   p.Heading,
+		Generated: time.Now().Format("January 2, 2006 15:04"),
+		Stats:     append(slices.Clone(p.Stats), p.Streaks...),
+	}
+
+	for _, c := range p.lists() {
+		if !p.Full && c.title != "Languages" {
+			continue
+		}
+		if rows := htmlBars(c.items, c.limit); len(rows) > 0 {
+			report.Cards = append(report.Cards, htmlCard{Title: c.title, Rows: rows})
+		}
+	}
+
+	if len(days) > 0 {
+		report.Heatmap = heatmapSVG(days)
+	}
+
+	return htmlTemplate.Execute(w, report)
+}
+
+// htmlBars follows graphStr: items under a minute are skipped, bars are relative to the longest item
+func htmlBars(items []types.StatItem, limit int) []htmlBar {
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	maxSeconds := 0.0
+	for _, item := range items {
+		maxSeconds = max(maxSeconds, item.TotalSeconds)
+	}
+	if maxSeconds == 0 {
+		return nil
+	}
+	var rows []htmlBar
+	for _, item := range items {
+		if item.TotalSeconds < 60 {
+			continue
+		}
+		rows = append(rows, htmlBar{
+			Name:    item.Name,
+			Time:    timeFmt(item.TotalSeconds),
+			Percent: item.TotalSeconds / maxSeconds * 100,
+		})
+	}
+	return rows
+}
+
+// heatmapSVG draws the calendar grid, every cell has a <title> so the times show up on hover and in screen readers
+func heatmapSVG(days []types.DayData) template.HTML {
+	const cell, gap, labelWidth, labelHeight = 12, 3, 28, 16
+	weeks := calendarGrid(days)
+	if len(weeks) == 0 {
+		return ""
+	}
+
+	width := labelWidth + len(weeks)*(cell+gap)
+	height := labelHeight + 7*(cell+gap)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="heatmap" xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="Daily activity heatmap">`, width, height)
+
+	for row, name := range []string{"Mon", "", "Wed", "", "Fri", "", "Sun"} {
+		if name != "" {
+			fmt.Fprintf(&b, `<text x="0" y="%d" class="label">%s</text>`, labelHeight+row*(cell+gap)+cell-2, name)
+		}
+	}
+
+	thresholds := levelThresholds(weeks)
+	labels := monthLabels(weeks)
+	for _, col := range slices.Sorted(maps.Keys(labels)) {
+		fmt.Fprintf(&b, `<text x="%d" y="11" class="label">%s</text>`, labelWidth+col*(cell+gap), labels[col])
+	}
+
+	for col, week := range weeks {
+		x := labelWidth + col*(cell+gap)
+		for row, day := range week {
+			if day == nil {
+				continue
+			}
+			y := labelHeight + row*(cell+gap)
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %s</title></rect>`,
+				x, y, cell, cell, heatmapFill(heatmapLevel(day.seconds, thresholds)), day.date.Format("Mon, Jan 2 2006"), timeFmt(day.seconds))
+		}
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// heatmapFill is the theme's color for a level, same shades as the terminal heatmap. The themes' empty
+// cell is meant for dark terminals, on the light page it'd look like activity
+func heatmapFill(level int) string {
+	if level == 0 {
+		return "#ebedf0"
+	}
+	return heatmapTheme.Colors[level]
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>wakafetch: {{.Heading}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; color: #24292f; background: #f6f8fa; margin: 0; padding: 24px; }
+  main { max-width: 960px; margin: 0 auto; }
+  h1 { color: #0550ae; font-size: 1.5em; margin: 0 0 4px; }
+  .generated { color: #6e7781; font-size: 0.85em; margin: 0 0 20px; }
+  .cards { display: grid; grid-template-columns: repeat(auto-fill, minmax(300px, 1fr)); gap: 16px; }
+  .card { background: #fff; border: 1px solid #d0d7de; border-radius: 8px; padding: 12px 16px; }
+  .card h2 { color: #9a6700; font-size: 1em; margin: 0 0 8px; }
+  .wide { grid-column: 1 / -1; overflow-x: auto; }
+  table { border-collapse: collapse; width: 100%; }
+  th { color: #0550ae; text-align: left; font-weight: 600; padding: 2px 12px 2px 0; white-space: nowrap; }
+  td { padding: 2px 0; }
+  .bar-row td { padding: 3px 8px 3px 0; white-space: nowrap; }
+  .bar-row .name { max-width: 140px; overflow: hidden; text-overflow: ellipsis; }
+  .bar-row .bar { width: 100%; }
+  .bar-row .time { color: #1a7f37; text-align: right; font-variant-numeric: tabular-nums; padding-right: 0; }
+  .bar svg { display: block; }
+  .heatmap .label { font-size: 10px; fill: #6e7781; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Heading}}</h1>
+<p class="generated">Generated by wakafetch on {{.Generated}}</p>
+<div class="cards">
+  <section class="card">
+    <h2>Stats</h2>
+    <table>
+      {{- range .Stats}}
+      <tr><th scope="row">{{.Key}}</th><td>{{.Val}}</td></tr>
+      {{- end}}
+    </table>
+  </section>
+  {{- range .Cards}}
+  <section class="card">
+    <h2>{{.Title}}</h2>
+    <table>
+      {{- range .Rows}}
+      <tr class="bar-row">
+        <td class="name" title="{{.Name}}">{{.Name}}</td>
+        <td class="bar"><svg width="100%" height="8" aria-hidden="true"><rect width="100%" height="8" rx="4" fill="#eaeef2"/><rect width="{{printf "%.1f" .Percent}}%" height="8" rx="4" fill="#2da44e"/></svg></td>
+        <td class="time">{{.Time}}</td>
+      </tr>
+      {{- end}}
+    </table>
+  </section>
+  {{- end}}
+  {{- if .Heatmap}}
+  <section class="card wide">
+    <h2>Heatmap</h2>
+    {{.Heatmap}}
+  </section>
+  {{- end}}
+</div>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// with markdown output on, the Display functions print GitHub flavoured markdown tables instead of cards,
+// for pasting into issues, chats and standup notes. Every list is included, not just the --full ones
+
+var markdownOutput bool
+
+func UseMarkdown() {
+	markdownOutput = true
+}
+
+func markdownPayload(w io.Writer, p *DisplayPayload) {
+	// with --compare, every table gets a change column
+	comparing := p.prev != nil
+
+	fmt.Fprintf(w, "## %s\n\n", markdownEscape(p.Heading))
+	if comparing {
+		fmt.Fprintln(w, "| Stat | Value | Change |")
+		fmt.Fprintln(w, "| --- | --- | --- |")
+	} else {
+		fmt.Fprintln(w, "| Stat | Value |")
+		fmt.Fprintln(w, "| --- | --- |")
+	}
+	for _, f := range p.Stats {
+		if comparing {
+			fmt.Fprintf(w, "| %s | %s | %s |\n", markdownEscape(f.Key), markdownEscape(f.Val), p.deltas[f.Key])
+		} else {
+			fmt.Fprintf(w, "| %s | %s |\n", markdownEscape(f.Key), markdownEscape(f.Val))
+		}
+	}
+
+	if len(p.Streaks) > 0 {
+		fmt.Fprint(w, "\n### Streaks\n\n")
+		fmt.Fprintln(w, "| Stat | Value |")
+		fmt.Fprintln(w, "| --- | --- |")
+		for _, f := range p.Streaks {
+			fmt.Fprintf(w, "| %s | %s |\n", f.Key, f.Val)
+		}
+	}
+
+	for _, list := range p.lists() {
+		items := list.items
+		if list.limit > 0 && list.limit < len(items) {
+			items = items[:list.limit]
+		}
+		total := 0.0
+		for _, item := range list.items {
+			total += item.TotalSeconds
+		}
+		if total == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n### %s\n\n", list.title)
+		if comparing {
+			fmt.Fprintln(w, "| Name | Time | Percent | Change |")
+			fmt.Fprintln(w, "| --- | ---: | ---: | ---: |")
+		} else {
+			fmt.Fprintln(w, "| Name | Time | Percent |")
+			fmt.Fprintln(w, "| --- | ---: | ---: |")
+		}
+		for _, item := range items {
+			if item.TotalSeconds < 60 {
+				continue
+			}
+			row := fmt.Sprintf("| %s | %s | %.1f%% |", markdownEscape(item.Name), timeFmt(item.TotalSeconds), item.TotalSeconds/total*100)
+			if comparing {
+				row += " " + timeDelta(item.TotalSeconds, p.prev[list.title][item.Name]) + " |"
+			}
+			fmt.Fprintln(w, row)
+		}
+	}
+
+	hoursTotal := 0.0
+	for _, secs := range p.Hours {
+		hoursTotal += secs
+	}
+	if hoursTotal > 0 {
+		fmt.Fprint(w, "\n### By Hour\n\n")
+		fmt.Fprintln(w, "| Hour | Time | Percent |")
+		fmt.Fprintln(w, "| --- | ---: | ---: |")
+		for h, secs := range p.Hours {
+			if secs >= 60 {
+				fmt.Fprintf(w, "| %02d:00 | %s | %.1f%% |\n", h, timeFmt(secs), secs/hoursTotal*100)
+			}
+		}
+	}
+}
+
+func markdownBreakdown(w io.Writer, days []types.DayData, heading string) {
+	fmt.Fprintf(w, "## %s\n\n", markdownEscape(heading))
+	header, align := "| Date | Time | Language | Project |", "| --- | ---: | --- | --- |"
+	for _, g := range dayGoals {
+		header += " " + markdownEscape(g.Name) + " |"
+		align += " :---: |"
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, align)
+	for _, day := range sortDaysDesc(days) {
+		if !IsActiveDay(day) {
+			continue
+		}
+		row := fmt.Sprintf("| %s | %s | %s | %s |",
+			formatDailyDate(day.Range.Start),
+			timeFmt(day.GrandTotal.TotalSeconds),
+			markdownEscape(topItemName(day.Languages, false)),
+			markdownEscape(topItemName(day.Projects, true)),
+		)
+		for _, g := range dayGoals {
+			if g.Met(day) {
+				row += " ✓ |"
+			} else {
+				row += " ✗ |"
+			}
+		}
+		fmt.Fprintln(w, row)
+	}
+}
+
+// markdownEscape keeps names like `a|b` or `*.go` from breaking the table or turning into formatting
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`").Replace(s)
+}
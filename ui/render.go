@@ -2,10 +2,13 @@ package ui
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -60,18 +63,66 @@ func renderCardSection(section CardSection) {
 }
 
 func render(p *DisplayPayload) {
-	fields, fieldsWidth := fieldsStr(p.Heading, p.Stats)
+	if markdownOutput {
+		markdownPayload(displayOut, p)
+		return
+	}
+	fields, fieldsWidth := fieldsStr(p.Heading, p.Stats, p.deltas)
+	streakLines, streakWidth := streaksStr(p.Streaks)
 	langLimit := len(fields)
-	langGraph, langWidth := graphStr(p.Languages, langLimit)
+	langGraph, langWidth := graphStr(p.Languages, langLimit, p.prev["Languages"])
+	weekdayLines, weekdayWidth := graphStr(p.Weekdays, 0, p.prev[weekdayTitle])
+	hourLines, hourWidth := hoursStr(p.Hours)
 	shrink := getTerminalCols() < 96
 
-	if p.Full {
-		projectsLines, projectsWidth := graphStr(p.Projects, 0)
-		categoriesLines, categoriesWidth := graphStr(p.Categories, 0)
-		machinesLines, machinesWidth := graphStr(p.Machines, 0)
-		editorsLines, editorsWidth := graphStr(p.Editors, 0)
-		osLines, osWidth := graphStr(p.OperatingSystems, 0)
-		entitiesLines, entitiesWidth := graphStr(p.Entities, 5)
+	if p.Project != "" {
+		branchesLines, branchesWidth := graphStr(p.Branches, 0, p.prev["Branches"])
+		entitiesLines, entitiesWidth := graphStr(p.Entities, 10, p.prev["Entities"])
+		editorsLines, editorsWidth := graphStr(p.Editors, 0, p.prev["Editors"])
+		categoriesLines, categoriesWidth := graphStr(p.Categories, 0, p.prev["Categories"])
+		var projectSection CardSection
+		if !shrink {
+			projectSection = CardSection{
+				Left: []CardConfig{
+					{Title: "Languages", Lines: langGraph, Width: langWidth},
+					{Title: "Branches", Lines: branchesLines, Width: branchesWidth},
+					{Title: "Entities", Lines: entitiesLines, Width: entitiesWidth},
+					{Title: "By Hour", Lines: hourLines, Width: hourWidth},
+				},
+				Right: []CardConfig{
+					{Title: "Stats", Lines: fields, Width: fieldsWidth},
+					{Title: "Streaks", Lines: streakLines, Width: streakWidth},
+					{Title: "Editors", Lines: editorsLines, Width: editorsWidth},
+					{Title: "Categories", Lines: categoriesLines, Width: categoriesWidth},
+					{Title: weekdayTitle, Lines: weekdayLines, Width: weekdayWidth},
+				},
+			}
+		} else {
+			projectSection = CardSection{
+				Left: []CardConfig{
+					{Title: "Languages", Lines: langGraph, Width: langWidth},
+					{Title: "Stats", Lines: fields, Width: fieldsWidth},
+					{Title: "Streaks", Lines: streakLines, Width: streakWidth},
+					{Title: "Branches", Lines: branchesLines, Width: branchesWidth},
+					{Title: "Entities", Lines: entitiesLines, Width: entitiesWidth},
+					{Title: "Editors", Lines: editorsLines, Width: editorsWidth},
+					{Title: "Categories", Lines: categoriesLines, Width: categoriesWidth},
+					{Title: weekdayTitle, Lines: weekdayLines, Width: weekdayWidth},
+					{Title: "By Hour", Lines: hourLines, Width: hourWidth},
+				},
+				Right: []CardConfig{},
+			}
+		}
+		renderCardSection(projectSection)
+	} else if p.Full {
+		projectsLines, projectsWidth := graphStr(p.Projects, 0, p.prev["Projects"])
+		categoriesLines, categoriesWidth := graphStr(p.Categories, 0, p.prev["Categories"])
+		machinesLines, machinesWidth := graphStr(p.Machines, 0, p.prev["Machines"])
+		editorsLines, editorsWidth := graphStr(p.Editors, 0, p.prev["Editors"])
+		osLines, osWidth := graphStr(p.OperatingSystems, 0, p.prev["Operating Systems"])
+		entitiesLines, entitiesWidth := graphStr(p.Entities, 5, p.prev["Entities"])
+		branchesLines, branchesWidth := graphStr(p.Branches, 5, p.prev["Branches"])
+		dependenciesLines, dependenciesWidth := graphStr(p.Dependencies, 5, p.prev["Dependencies"])
 		var fullSection CardSection
 		if !shrink {
 			fullSection = CardSection{
@@ -80,12 +131,17 @@ func render(p *DisplayPayload) {
 					{Title: "Projects", Lines: projectsLines, Width: projectsWidth},
 					{Title: "Categories", Lines: categoriesLines, Width: categoriesWidth},
 					{Title: "Entities", Lines: entitiesLines, Width: entitiesWidth},
+					{Title: "Branches", Lines: branchesLines, Width: branchesWidth},
+					{Title: "By Hour", Lines: hourLines, Width: hourWidth},
 				},
 				Right: []CardConfig{
 					{Title: "Stats", Lines: fields, Width: fieldsWidth},
+					{Title: "Streaks", Lines: streakLines, Width: streakWidth},
 					{Title: "Editors", Lines: editorsLines, Width: editorsWidth},
 					{Title: "Operating Systems", Lines: osLines, Width: osWidth},
 					{Title: "Machines", Lines: machinesLines, Width: machinesWidth},
+					{Title: "Dependencies", Lines: dependenciesLines, Width: dependenciesWidth},
+					{Title: weekdayTitle, Lines: weekdayLines, Width: weekdayWidth},
 				},
 			}
 		} else {
@@ -93,12 +149,17 @@ func render(p *DisplayPayload) {
 				Left: []CardConfig{
 					{Title: "Languages", Lines: langGraph, Width: langWidth},
 					{Title: "Stats", Lines: fields, Width: fieldsWidth},
+					{Title: "Streaks", Lines: streakLines, Width: streakWidth},
 					{Title: "Projects", Lines: projectsLines, Width: projectsWidth},
 					{Title: "Categories", Lines: categoriesLines, Width: categoriesWidth},
 					{Title: "Editors", Lines: editorsLines, Width: editorsWidth},
 					{Title: "Entities", Lines: entitiesLines, Width: entitiesWidth},
 					{Title: "Operating Systems", Lines: osLines, Width: osWidth},
 					{Title: "Machines", Lines: machinesLines, Width: machinesWidth},
+					{Title: "Branches", Lines: branchesLines, Width: branchesWidth},
+					{Title: "Dependencies", Lines: dependenciesLines, Width: dependenciesWidth},
+					{Title: weekdayTitle, Lines: weekdayLines, Width: weekdayWidth},
+					{Title: "By Hour", Lines: hourLines, Width: hourWidth},
 				},
 				Right: []CardConfig{},
 			}
@@ -132,7 +193,8 @@ func formatRangeHeading(rangeStr string) string {
 	return strings.ToUpper(lower[:1]) + lower[1:]
 }
 
-func fieldsStr(heading string, stats []Field) ([]string, int) {
+// fieldsStr lays out the stats under the heading, deltas (from --compare, can be nil) go in a column after the values
+func fieldsStr(heading string, stats []Field, deltas map[string]string) ([]string, int) {
 	if len(stats) == 0 {
 		return []string{}, 0
 	}
@@ -141,10 +203,19 @@ func fieldsStr(heading string, stats []Field) ([]string, int) {
 	for _, kv := range stats {
 		maxKeyLength = max(maxKeyLength, len(kv.Key))
 	}
+	deltaCol := 0
+	for _, kv := range stats {
+		if deltas[kv.Key] != "" {
+			deltaCol = max(deltaCol, maxKeyLength+2+utf8.RuneCountInString(kv.Val)+2)
+		}
+	}
 
-	maxWidth := len(heading)
+	maxWidth := utf8.RuneCountInString(heading)
 	for _, kv := range stats {
-		lineWidth := maxKeyLength + 2 + len(kv.Val)
+		lineWidth := maxKeyLength + 2 + utf8.RuneCountInString(kv.Val)
+		if delta := deltas[kv.Key]; delta != "" {
+			lineWidth = deltaCol + utf8.RuneCountInString(delta)
+		}
 		maxWidth = max(maxWidth, lineWidth)
 	}
 
@@ -159,22 +230,29 @@ func fieldsStr(heading string, stats []Field) ([]string, int) {
 	}
 	output = append(output, headingLine)
 
-	separatorLine := fmt.Sprintf("%-*s", maxWidth, strings.Repeat("-", len(heading)))
+	separatorLine := fmt.Sprintf("%-*s", maxWidth, strings.Repeat("-", utf8.RuneCountInString(heading)))
 	output = append(output, separatorLine)
 
 	for _, kv := range stats {
 		rawLine := fmt.Sprintf("%-*s%s", maxKeyLength+2, kv.Key, kv.Val)
-		paddedLine := fmt.Sprintf("%-*s", maxWidth, rawLine)
-		styledLine := Clr.BoldBlue + paddedLine[:maxKeyLength+2] + Clr.Reset + paddedLine[maxKeyLength+2:]
+		delta := deltas[kv.Key]
+		if delta != "" {
+			rawLine = fmt.Sprintf("%-*s", deltaCol, rawLine)
+		}
+		styledLine := Clr.BoldBlue + rawLine[:maxKeyLength+2] + Clr.Reset + rawLine[maxKeyLength+2:] +
+			colorDelta(delta, maxWidth-utf8.RuneCountInString(rawLine))
 		output = append(output, styledLine)
 	}
 
 	return output, maxWidth
 }
 
+// displayOut is where the Display functions print, swapped out by Capture
+var displayOut io.Writer = os.Stdout
+
 func printStrs(strs []string) {
 	for _, str := range strs {
-		fmt.Println(str)
+		fmt.Fprintln(displayOut, str)
 	}
 }
 
@@ -183,10 +261,10 @@ func printLeftRight(left, right []string, spacing, leftWidth int) {
 
 	for i, line := range left {
 		if i >= len(right) {
-			fmt.Println(line)
+			fmt.Fprintln(displayOut, line)
 			continue
 		}
-		fmt.Println(line + space + right[i])
+		fmt.Fprintln(displayOut, line+space+right[i])
 	}
 	if len(left) == 0 {
 		spacing = 0
@@ -198,38 +276,45 @@ func printLeftRight(left, right []string, spacing, leftWidth int) {
 			pad = leftWidth + spacing
 		}
 		for i := len(left); i < len(right); i++ {
-			fmt.Println(strings.Repeat(" ", pad) + right[i])
+			fmt.Fprintln(displayOut, strings.Repeat(" ", pad)+right[i])
 		}
 	}
 }
 
 func getTerminalCols() int {
+	_, cols := getTerminalSize()
+	return cols
+}
+
+// getTerminalSize returns the rows and columns of the terminal, 9999 for each if it can't tell
+func getTerminalSize() (int, int) {
 	const fallback = 9999
 	var sizeStr string
 	switch runtime.GOOS {
 	case "linux":
 		out, err := exec.Command("stty", "-F", "/dev/tty", "size").Output()
 		if err != nil || len(out) == 0 {
-			return fallback
+			return fallback, fallback
 		}
 		sizeStr = strings.TrimSpace(string(out))
 	case "darwin":
 		out, err := exec.Command("sh", "-c", "stty size < /dev/tty").Output()
 		if err != nil || len(out) == 0 {
-			return fallback
+			return fallback, fallback
 		}
 		sizeStr = strings.TrimSpace(string(out))
 	default:
-		return fallback
+		return fallback, fallback
 	}
 
 	size := strings.Split(sizeStr, " ")
 	if len(size) < 2 {
-		return fallback
+		return fallback, fallback
 	}
-	colStr := size[1]
-	if width, err := strconv.Atoi(strings.TrimSpace(string(colStr))); err == nil {
-		return width
+	rows, err1 := strconv.Atoi(strings.TrimSpace(size[0]))
+	cols, err2 := strconv.Atoi(strings.TrimSpace(size[1]))
+	if err1 != nil || err2 != nil {
+		return fallback, fallback
 	}
-	return fallback
+	return rows, cols
 }
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// minActiveSeconds is how much coding makes a day count, for the active days and daily average,
+// the breakdown rows and the streaks
+var minActiveSeconds = 60.0
+
+func SetMinActive(d time.Duration) {
+	minActiveSeconds = d.Seconds()
+}
+
+// IsActiveDay is whether the day has at least --min-active of coding
+func IsActiveDay(day types.DayData) bool {
+	return day.GrandTotal.TotalSeconds >= minActiveSeconds
+}
+
+// dayRun is a run of consecutive active (or inactive) days
+type dayRun struct {
+	length     int
+	start, end time.Time
+}
+
+func (r dayRun) String() string {
+	if r.length == 0 {
+		return "None"
+	}
+	if r.start.Equal(r.end) {
+		return fmt.Sprintf("%s (%s)", r.lengthStr(), r.start.Format("Jan 2"))
+	}
+	return fmt.Sprintf("%s (%s to %s)", r.lengthStr(), r.start.Format("Jan 2"), r.end.Format("Jan 2"))
+}
+
+func (r dayRun) lengthStr() string {
+	if r.length == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", r.length)
+}
+
+// streakFields are the Streaks card. Days missing from the response count as inactive,
+// and an inactive last day (usually today) doesn't break the current streak yet
+func streakFields(days []types.DayData) []Field {
+	active := make(map[string]bool)
+	var first, last time.Time
+	for _, day := range days {
+		dateStr := day.Range.Date
+		if dateStr == "" {
+			dateStr = strings.Split(day.Range.Start, "T")[0]
+		}
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if date.After(last) {
+			last = date
+		}
+		if IsActiveDay(day) {
+			active[date.Format("2006-01-02")] = true
+		}
+	}
+	if len(active) == 0 {
+		return nil
+	}
+
+	var longest, gap, run, gapRun dayRun
+	totalDays := 0
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		totalDays++
+		if active[d.Format("2006-01-02")] {
+			run = extendRun(run, d)
+			gapRun = dayRun{}
+			if run.length > longest.length {
+				longest = run
+			}
+		} else {
+			gapRun = extendRun(gapRun, d)
+			run = dayRun{}
+			if gapRun.length > gap.length {
+				gap = gapRun
+			}
+		}
+	}
+
+	current := 0
+	d := last
+	if !active[d.Format("2006-01-02")] {
+		d = d.AddDate(0, 0, -1)
+	}
+	for ; !d.Before(first) && active[d.Format("2006-01-02")]; d = d.AddDate(0, 0, -1) {
+		current++
+	}
+
+	perWeek := float64(len(active)) / float64(totalDays) * 7
+	return []Field{
+		{"Current Streak", dayRun{length: current}.lengthStr()},
+		{"Longest Streak", longest.String()},
+		{"Longest Gap", gap.String()},
+		{"Days per Week", fmt.Sprintf("%.1f", perWeek)},
+	}
+}
+
+func extendRun(r dayRun, d time.Time) dayRun {
+	if r.length == 0 {
+		r.start = d
+	}
+	r.length++
+	r.end = d
+	return r
+}
+
+// streaksStr lays out the fields like the stats, without a heading
+func streaksStr(fields []Field) ([]string, int) {
+	maxKeyLength, width := 0, 0
+	for _, f := range fields {
+		maxKeyLength = max(maxKeyLength, len(f.Key))
+	}
+	for _, f := range fields {
+		width = max(width, maxKeyLength+2+len(f.Val))
+	}
+	output := make([]string, 0, len(fields))
+	for _, f := range fields {
+		output = append(output, Clr.BoldBlue+fmt.Sprintf("%-*s", maxKeyLength+2, f.Key)+Clr.Reset+fmt.Sprintf("%-*s", width-maxKeyLength-2, f.Val))
+	}
+	return output, width
+}
@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SVG output draws exactly what the Display functions print: the lines are captured,
+// and every ANSI color is mapped to a theme color. Bars and heatmap cells become rects,
+// since fonts with 🬋 are rare outside terminals
+
+type SVGTheme struct {
+	Background string
+	Text       string
+	MidGray    string // card borders
+	Red        string
+	Yellow     string
+	Blue       string
+	Green      string
+	Gray       string
+	HeatEmpty  string // heatmap cell without activity
+}
+
+var SVGThemes = map[string]SVGTheme{
+	"dark": {
+		Background: "#0d1117",
+		Text:       "#c9d1d9",
+		MidGray:    "#808080",
+		Red:        "#f85149",
+		Yellow:     "#d29922",
+		Blue:       "#58a6ff",
+		Green:      "#3fb950",
+		Gray:       "#484f58",
+		HeatEmpty:  "#161b22",
+	},
+	"light": {
+		Background: "#ffffff",
+		Text:       "#24292f",
+		MidGray:    "#8c959f",
+		Red:        "#cf222e",
+		Yellow:     "#9a6700",
+		Blue:       "#0969da",
+		Green:      "#1a7f37",
+		Gray:       "#d0d7de",
+		HeatEmpty:  "#ebedf0",
+	},
+}
+
+func SVGThemeNames() []string {
+	return slices.Sorted(maps.Keys(SVGThemes))
+}
+
+const (
+	svgFontSize   = 14
+	svgCharWidth  = 8.4 // 0.6em, the advance of most monospace fonts
+	svgLineHeight = 18
+	svgPadding    = 16
+)
+
+// WriteSVG runs display with colors on and writes what it printed as an SVG image
+func WriteSVG(w io.Writer, themeName string, display func()) error {
+	theme, ok := SVGThemes[themeName]
+	if !ok {
+		return fmt.Errorf("unknown SVG theme: '%s', must be one of %s", themeName, strings.Join(SVGThemeNames(), ", "))
+	}
+
+	// the heatmap in truecolor, whatever the terminal has, its escapes are turned back into colors
+	depth := heatmapDepth
+	heatmapDepth = depthTrue
+	captured := Capture(display)
+	heatmapDepth = depth
+	if captured == "" {
+		return fmt.Errorf("nothing to render")
+	}
+	lines := strings.Split(strings.TrimRight(captured, "\n"), "\n")
+
+	cols := 0
+	for _, line := range lines {
+		cols = max(cols, utf8.RuneCountInString(ansiRegex.ReplaceAllString(line, "")))
+	}
+	width := int(float64(cols)*svgCharWidth) + 2*svgPadding
+	height := len(lines)*svgLineHeight + 2*svgPadding
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" rx="6" fill="%s"/>`+"\n", theme.Background)
+	fmt.Fprintf(&b, `<g font-family="ui-monospace, SFMono-Regular, Menlo, Consolas, 'DejaVu Sans Mono', monospace" font-size="%d" xml:space="preserve">`+"\n", svgFontSize)
+	for i, line := range lines {
+		svgLine(&b, line, svgPadding+i*svgLineHeight, theme)
+	}
+	b.WriteString("</g>\n</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+type svgStyle struct {
+	fill string
+	bold bool
+}
+
+// svgLine draws a line segment by segment, each at its own column so a font with odd widths can't misalign the cards.
+// Consecutive text with the same style is merged, cardify colors every border char on its own
+func svgLine(b *strings.Builder, line string, top int, theme SVGTheme) {
+	type segment struct {
+		text  string
+		style svgStyle
+	}
+	var segments []segment
+	style := svgStyle{fill: theme.Text}
+	pos := 0
+	for _, loc := range append(ansiRegex.FindAllStringIndex(line, -1), []int{len(line), len(line)}) {
+		if text := line[pos:loc[0]]; text != "" {
+			if last := len(segments) - 1; last >= 0 && segments[last].style == style {
+				segments[last].text += text
+			} else {
+				segments = append(segments, segment{text, style})
+			}
+		}
+		if loc[0] < len(line) {
+			style = applyANSI(style, line[loc[0]:loc[1]], theme)
+		}
+		pos = loc[1]
+	}
+
+	col := 0
+	baseline := float64(top) + svgLineHeight*0.75
+	for _, seg := range segments {
+		col = svgText(b, seg.text, col, top, baseline, seg.style)
+	}
+}
+
+// svgText draws text starting at col and returns the column after it. Bar and heatmap runs become rects
+func svgText(b *strings.Builder, text string, col, top int, baseline float64, style svgStyle) int {
+	isGlyph := func(c rune) bool { return string(c) == barChar || string(c) == heatmapChar }
+	for text != "" {
+		r, size := utf8.DecodeRuneInString(text)
+		x := svgPadding + float64(col)*svgCharWidth
+
+		switch string(r) {
+		case barChar:
+			end := strings.IndexFunc(text, func(c rune) bool { return c != r })
+			if end == -1 {
+				end = len(text)
+			}
+			n := utf8.RuneCountInString(text[:end])
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="4" rx="2" fill="%s"/>`+"\n",
+				x+1, float64(top)+svgLineHeight/2-2, float64(n)*svgCharWidth-2, style.fill)
+			text = text[end:]
+			col += n
+		case heatmapChar:
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="1.5" fill="%s"/>`+"\n",
+				x, float64(top)+(svgLineHeight-svgCharWidth)/2, svgCharWidth, svgCharWidth, style.fill)
+			text = text[size:]
+			col++
+		default:
+			end := strings.IndexFunc(text, isGlyph)
+			if end == -1 {
+				end = len(text)
+			}
+			run := text[:end]
+			n := utf8.RuneCountInString(run)
+			if strings.TrimSpace(run) != "" {
+				weight := ""
+				if style.bold {
+					weight = ` font-weight="bold"`
+				}
+				fmt.Fprintf(b, `<text x="%.1f" y="%.1f" fill="%s"%s textLength="%.1f" lengthAdjust="spacingAndGlyphs">%s</text>`+"\n",
+					x, baseline, style.fill, weight, float64(n)*svgCharWidth, html.EscapeString(run))
+			}
+			text = text[end:]
+			col += n
+		}
+	}
+	return col
+}
+
+// applyANSI maps the escapes in Clr (and the heatmap's 24-bit shades) to theme colors
+func applyANSI(style svgStyle, code string, theme SVGTheme) svgStyle {
+	switch code {
+	case defaultColors.Reset:
+		return svgStyle{fill: theme.Text}
+	case defaultColors.Bold:
+		style.bold = true
+	case defaultColors.BoldBlue:
+		return svgStyle{fill: theme.Blue, bold: true}
+	case defaultColors.Blue:
+		style.fill = theme.Blue
+	case defaultColors.Green:
+		style.fill = theme.Green
+	case defaultColors.Yellow:
+		style.fill = theme.Yellow
+	case defaultColors.Red:
+		style.fill = theme.Red
+	case defaultColors.Gray:
+		style.fill = theme.Gray
+	case defaultColors.MidGray:
+		style.fill = theme.MidGray
+	default:
+		// heatmap cells: \x1b[38;2;R;G;Bm in the heatmap theme's colors, the empty one follows the svg theme
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(code, "\x1b["), "m"), ";")
+		if len(parts) == 5 && parts[0] == "38" && parts[1] == "2" {
+			var rgb [3]int
+			for i, part := range parts[2:] {
+				rgb[i], _ = strconv.Atoi(part)
+			}
+			style.fill = fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+			if style.fill == heatmapTheme.Colors[0] {
+				style.fill = theme.HeatEmpty
+			}
+		}
+	}
+	return style
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// heatmap shades: a theme has a color per level, shown in truecolor when the terminal has it
+// and as the nearest of the 256 or the basic 16 colors otherwise
+
+type HeatmapTheme struct {
+	Colors [heatmapLevels]string // #rrggbb, level 0 (no activity) first
+	Basic  [heatmapLevels]string // SGR params for 16 color terminals
+}
+
+var HeatmapThemes = map[string]HeatmapTheme{
+	"green": {
+		Colors: [heatmapLevels]string{"#2d333b", "#0e4429", "#006d32", "#26a641", "#39d353"},
+		Basic:  [heatmapLevels]string{"90", "2;32", "32", "92", "1;92"},
+	},
+	"halloween": {
+		Colors: [heatmapLevels]string{"#2d333b", "#631c03", "#bd561d", "#fa7a18", "#fddf68"},
+		Basic:  [heatmapLevels]string{"90", "31", "33", "93", "1;93"},
+	},
+	"blue": {
+		Colors: [heatmapLevels]string{"#2d333b", "#0a3069", "#0969da", "#54aeff", "#b6e3ff"},
+		Basic:  [heatmapLevels]string{"90", "2;34", "34", "94", "1;96"},
+	},
+	"mono": {
+		Colors: [heatmapLevels]string{"#2d333b", "#4e4e4e", "#808080", "#b2b2b2", "#eeeeee"},
+		Basic:  [heatmapLevels]string{"90", "2;37", "37", "97", "1;97"},
+	},
+}
+
+func HeatmapThemeNames() []string {
+	return slices.Sorted(maps.Keys(HeatmapThemes))
+}
+
+var heatmapTheme = HeatmapThemes["green"]
+
+// heatmapThresholds are the least seconds for levels 1 to 4, nil for the quartiles of the active days
+var heatmapThresholds []float64
+
+func SetHeatmapStyle(theme string, thresholds []float64) {
+	heatmapTheme = HeatmapThemes[theme]
+	heatmapThresholds = thresholds
+}
+
+type colorDepth int
+
+const (
+	depth16 colorDepth = iota
+	depth256
+	depthTrue
+)
+
+var heatmapDepth = detectColorDepth()
+
+// detectColorDepth goes by $COLORTERM and $TERM, Windows Terminal sets neither but has truecolor
+func detectColorDepth() colorDepth {
+	colorterm := strings.ToLower(os.Getenv("COLORTERM"))
+	switch {
+	case colorterm == "truecolor" || colorterm == "24bit" || os.Getenv("WT_SESSION") != "":
+		return depthTrue
+	case strings.Contains(os.Getenv("TERM"), "256color"):
+		return depth256
+	}
+	return depth16
+}
+
+// shadeEscape is the escape for a level of the theme, at the terminal's color depth
+func shadeEscape(level int) string {
+	r, g, b := parseHex(heatmapTheme.Colors[level])
+	switch heatmapDepth {
+	case depthTrue:
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", r, g, b)
+	case depth256:
+		return fmt.Sprintf("\x1b[38;5;%dm", ansi256(r, g, b))
+	}
+	return "\x1b[" + heatmapTheme.Basic[level] + "m"
+}
+
+func parseHex(hex string) (int, int, int) {
+	var r, g, b int
+	fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
+	return r, g, b
+}
+
+// ansi256 is the closest color of the 6x6x6 cube or the gray ramp
+func ansi256(r, g, b int) int {
+	cube := func(v int) int {
+		if v < 48 {
+			return 0
+		}
+		return min(5, (v-35)/40)
+	}
+	level := func(i int) int {
+		if i == 0 {
+			return 0
+		}
+		return 55 + i*40
+	}
+	ri, gi, bi := cube(r), cube(g), cube(b)
+	cubeDist := sqDist(r, g, b, level(ri), level(gi), level(bi))
+
+	gray := min(23, max(0, ((r+g+b)/3-3)/10))
+	grayValue := 8 + gray*10
+	if sqDist(r, g, b, grayValue, grayValue, grayValue) < cubeDist {
+		return 232 + gray
+	}
+	return 16 + 36*ri + 6*gi + bi
+}
+
+func sqDist(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// the timeline is a day's sessions from /durations, a column per 20 minutes colored by the project with the most time in it
+
+const (
+	timelineCols     = 72
+	timelineProjects = 6 // projects with their own color, the rest are Other
+	// breaks up to this long don't end a session, like wakatime's keystroke timeout
+	sessionGap = 15 * time.Minute
+)
+
+// timelineGlyphs tell the projects apart when colors are off
+var timelineGlyphs = []string{"█", "▓", "▒", "░", "▚", "▞"}
+
+const otherGlyph = "▪"
+
+type span struct {
+	start, end time.Time
+	project    string
+}
+
+func DisplayTimeline(durations []types.Duration, day time.Time) {
+	spans := daySpans(durations, day)
+	if len(spans) == 0 {
+		Warnln("No coding sessions on %s", day.Format("Jan 2, 2006"))
+		return
+	}
+
+	totals := make(map[string]float64)
+	for _, s := range spans {
+		totals[s.project] += s.end.Sub(s.start).Seconds()
+	}
+	projects := mapToSortedStatItems(totals)
+	styles := make(map[string]int, len(projects)) // project -> index in the palette, timelineProjects for Other
+	for i, p := range projects {
+		styles[p.Name] = min(i, timelineProjects)
+	}
+
+	fields, fieldsWidth := fieldsStr(day.Format("Monday, Jan 2 2006"), sessionFields(spans), nil)
+	legend, legendWidth := timelineLegendStr(projects, styles)
+	legendCard, legendCardWidth := cardify(legend, "Projects", legendWidth, 0)
+	timeline, timelineWidth := timelineStr(spans, styles, day)
+	timelineCard, _ := cardify(timeline, "Timeline", timelineWidth, 0)
+
+	if getTerminalCols() < legendCardWidth+2+fieldsWidth {
+		printStrs(fields)
+		printStrs(legendCard)
+	} else {
+		printLeftRight(legendCard, fields, 2, legendCardWidth)
+	}
+	printStrs(timelineCard)
+}
+
+// daySpans clips the durations to the day (local time), sorted by start
+func daySpans(durations []types.Duration, day time.Time) []span {
+	dayEnd := day.AddDate(0, 0, 1)
+	var spans []span
+	for _, d := range durations {
+		start := time.Unix(0, int64(d.Time*float64(time.Second))).Local()
+		end := start.Add(time.Duration(d.Duration * float64(time.Second)))
+		if start.Before(day) {
+			start = day
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		if !end.After(start) {
+			continue
+		}
+		project := d.Project
+		if project == "" {
+			project = "Unknown"
+		}
+		spans = append(spans, span{start, end, project})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+	return spans
+}
+
+// session is spans with short breaks merged, coded leaves the breaks out
+type session struct {
+	span
+	coded float64
+}
+
+// sessionFields merges spans with short breaks into sessions. Averages and the longest session go by
+// coded time, the breaks in between don't count
+func sessionFields(spans []span) []Field {
+	var sessions []session
+	total := 0.0
+	for _, s := range spans {
+		secs := s.end.Sub(s.start).Seconds()
+		total += secs
+		last := len(sessions) - 1
+		if last >= 0 && s.start.Sub(sessions[last].end) <= sessionGap {
+			if s.end.After(sessions[last].end) {
+				sessions[last].end = s.end
+			}
+			sessions[last].coded += secs
+			continue
+		}
+		sessions = append(sessions, session{s, secs})
+	}
+
+	longest := sessions[0]
+	for _, s := range sessions {
+		if s.coded > longest.coded {
+			longest = s
+		}
+	}
+
+	return []Field{
+		{"Total Time", timeFmt(total)},
+		{"Active", fmt.Sprintf("%s to %s", spans[0].start.Format("15:04"), sessions[len(sessions)-1].end.Format("15:04"))},
+		{"Sessions", fmt.Sprintf("%d", len(sessions))},
+		{"Avg Session", timeFmt(total / float64(len(sessions)))},
+		{"Longest", fmt.Sprintf("%s (%s to %s)", timeFmt(longest.coded), longest.start.Format("15:04"), longest.end.Format("15:04"))},
+	}
+}
+
+// timelineStyle is the colored block of a palette index, or its glyph without colors
+func timelineStyle(style int) string {
+	palette := []string{Clr.Green, Clr.Blue, Clr.Yellow, Clr.Magenta, Clr.Cyan, Clr.Red}
+	switch {
+	case style == timelineProjects && Clr.Gray == "":
+		return otherGlyph
+	case style == timelineProjects:
+		return Clr.Gray + "█" + Clr.Reset
+	case Clr.Green == "":
+		return timelineGlyphs[style]
+	}
+	return palette[style] + "█" + Clr.Reset
+}
+
+func timelineStr(spans []span, styles map[string]int, day time.Time) ([]string, int) {
+	slot := 24 * time.Hour / timelineCols
+	row := ""
+	for col := range timelineCols {
+		from := day.Add(time.Duration(col) * slot)
+		to := from.Add(slot)
+		inSlot := make(map[string]time.Duration)
+		top := ""
+		for _, s := range spans {
+			start, end := s.start, s.end
+			if start.Before(from) {
+				start = from
+			}
+			if end.After(to) {
+				end = to
+			}
+			overlap := end.Sub(start)
+			if overlap <= 0 {
+				continue
+			}
+			inSlot[s.project] += overlap
+			if top == "" || inSlot[s.project] > inSlot[top] {
+				top = s.project
+			}
+		}
+		if top == "" || inSlot[top] < time.Minute {
+			row += Clr.Gray + "·" + Clr.Reset
+			continue
+		}
+		row += timelineStyle(styles[top])
+	}
+
+	axis := ""
+	for h := 0; h < 24; h += 6 {
+		axis += fmt.Sprintf("%-*s", timelineCols/4, fmt.Sprintf("%02d", h))
+	}
+	axis = axis[:timelineCols-2] + "24"
+	return []string{row, row, Clr.Gray + axis + Clr.Reset}, timelineCols
+}
+
+func timelineLegendStr(projects []types.StatItem, styles map[string]int) ([]string, int) {
+	names := projects
+	other := 0.0
+	if len(projects) > timelineProjects {
+		names = projects[:timelineProjects]
+		for _, p := range projects[timelineProjects:] {
+			other += p.TotalSeconds
+		}
+	}
+
+	maxNameLength := len("Other")
+	for _, p := range names {
+		maxNameLength = max(maxNameLength, len(p.Name))
+	}
+	maxSeconds := max(projects[0].TotalSeconds, other)
+
+	output := make([]string, 0, len(names)+1)
+	for _, p := range names {
+		output = append(output, timelineStyle(styles[p.Name])+" "+fmt.Sprintf("%-*s ", maxNameLength, p.Name)+Clr.Green+timeFmtPad(p.TotalSeconds, maxSeconds)+Clr.Reset)
+	}
+	if other > 0 {
+		output = append(output, timelineStyle(timelineProjects)+" "+fmt.Sprintf("%-*s ", maxNameLength, "Other")+Clr.Green+timeFmtPad(other, maxSeconds)+Clr.Reset)
+	}
+	return output, 2 + maxNameLength + 1 + len(timeFmtPad(maxSeconds, maxSeconds))
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/sahaj-b/wakafetch/types"
+)
+
+// the interactive mode draws one card at a time next to the stats, with a tab bar to move between cards.
+// The loop, keys and fetching live in main, this only turns the state into a frame
+
+type TUIState struct {
+	View    string // stats, daily or heatmap
+	Range   string // key of the range, shown in the header
+	Payload *DisplayPayload
+	Days    []types.DayData
+	Heading string // for the daily and heatmap views
+	Project string // drilled into, empty for all projects
+	Card    int
+	Row     int
+	Status  string // loading, errors
+	NoData  bool
+}
+
+type TUICard struct {
+	Title string
+	Items []types.StatItem
+}
+
+const tuiMaxItems = 50
+
+// TUICards are the navigable cards: the ones with data, without the items graphStr would hide
+func TUICards(p *DisplayPayload, project string) []TUICard {
+	if p == nil {
+		return nil
+	}
+	lists := []statList{
+		{"Languages", p.Languages, 0},
+		{"Projects", p.Projects, 0},
+		{"Editors", p.Editors, 0},
+		{"Categories", p.Categories, 0},
+		{"Operating Systems", p.OperatingSystems, 0},
+		{"Machines", p.Machines, 0},
+		{"Entities", p.Entities, tuiMaxItems},
+		{"Branches", p.Branches, 0},
+		{"Dependencies", p.Dependencies, 0},
+		{weekdayTitle, p.Weekdays, 0},
+	}
+	if project != "" {
+		// a single project's own dimensions first, the projects list would only contain itself
+		lists = []statList{
+			{"Languages", p.Languages, 0},
+			{"Branches", p.Branches, 0},
+			{"Entities", p.Entities, tuiMaxItems},
+			{"Editors", p.Editors, 0},
+			{"Categories", p.Categories, 0},
+			{weekdayTitle, p.Weekdays, 0},
+		}
+	}
+
+	var cards []TUICard
+	for _, list := range lists {
+		var items []types.StatItem
+		for _, item := range list.items {
+			if item.TotalSeconds >= 60 {
+				items = append(items, item)
+			}
+		}
+		if list.limit > 0 && len(items) > list.limit {
+			items = items[:list.limit]
+		}
+		if len(items) > 0 {
+			cards = append(cards, TUICard{list.title, items})
+		}
+	}
+	return cards
+}
+
+// RenderTUI returns the frame for the state
+func RenderTUI(s TUIState) string {
+	rows, _ := getTerminalSize()
+	var b strings.Builder
+
+	// header: views and range
+	for _, view := range []string{"stats", "daily", "heatmap"} {
+		if view == s.View {
+			b.WriteString(Clr.BoldBlue + "[" + view + "]" + Clr.Reset + " ")
+		} else {
+			b.WriteString(Clr.Gray + " " + view + " " + Clr.Reset + " ")
+		}
+	}
+	b.WriteString(Clr.Gray + "range: " + Clr.Reset + Clr.Green + s.Range + Clr.Reset)
+	if s.Project != "" {
+		b.WriteString(Clr.Gray + "  project: " + Clr.Reset + Clr.Yellow + s.Project + Clr.Reset)
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case s.NoData:
+		b.WriteString(Clr.Yellow + "No data available for the selected period" + Clr.Reset + "\n")
+	case s.Payload == nil:
+		// nothing fetched yet
+	case s.View == "daily":
+		b.WriteString(Capture(func() { DisplayBreakdown(s.Days, s.Heading) }))
+	case s.View == "heatmap":
+		b.WriteString(Capture(func() { DisplayHeatmap(s.Days, s.Heading) }))
+	default:
+		b.WriteString(tuiStatsView(s, rows))
+	}
+
+	if s.Status != "" {
+		b.WriteString("\n" + s.Status + "\n")
+	}
+	b.WriteString("\n" + Clr.Gray + tuiHelp(s) + Clr.Reset)
+	return b.String()
+}
+
+func tuiStatsView(s TUIState, termRows int) string {
+	cards := TUICards(s.Payload, s.Project)
+	var b strings.Builder
+
+	// tab bar
+	for i, card := range cards {
+		if i == s.Card {
+			b.WriteString(Clr.Bold + Clr.Yellow + "‹" + card.Title + "›" + Clr.Reset + " ")
+		} else {
+			b.WriteString(Clr.Gray + " " + card.Title + " " + Clr.Reset + " ")
+		}
+	}
+	b.WriteString("\n")
+
+	fields, _ := fieldsStr(s.Payload.Heading, s.Payload.Stats, nil)
+	if len(cards) == 0 {
+		return b.String() + strings.Join(fields, "\n") + "\n"
+	}
+	card := cards[min(s.Card, len(cards)-1)]
+	row := min(s.Row, max(len(card.Items)-1, 0))
+
+	// scroll so the selected row stays visible, leaving room for the header, tabs, borders and help
+	visible := max(len(fields), termRows-10)
+	first := 0
+	if row >= visible {
+		first = row - visible + 1
+	}
+	last := min(first+visible, len(card.Items))
+
+	lines, width := graphStr(card.Items, 0, nil)
+	lines = lines[first:last]
+	for i := range lines {
+		marker := "  "
+		if first+i == row {
+			marker = Clr.Yellow + "▶ " + Clr.Reset
+		}
+		lines[i] = marker + lines[i]
+	}
+	title := card.Title
+	if len(card.Items) > visible {
+		title = fmt.Sprintf("%s %d/%d", card.Title, row+1, len(card.Items))
+	}
+	cardLines, cardWidth := cardify(lines, title, width+2, 0)
+
+	return b.String() + Capture(func() { printLeftRight(cardLines, fields, 2, cardWidth) })
+}
+
+func tuiHelp(s TUIState) string {
+	help := "←/→ card  ↑/↓ select  "
+	if s.Project == "" {
+		help += "enter drill into project  "
+	} else {
+		help += "esc back  "
+	}
+	return help + "1-5 range  s/d/m view  q quit"
+}
+
+// RawMode turns off line buffering and echo on the terminal, returning a function that restores it.
+// Ctrl+C still sends SIGINT, so the caller can restore on interrupts too
+func RawMode() (func(), error) {
+	stty := func(args ...string) *exec.Cmd {
+		if runtime.GOOS == "linux" {
+			return exec.Command("stty", append([]string{"-F", "/dev/tty"}, args...)...)
+		}
+		return exec.Command("sh", "-c", "stty "+strings.Join(args, " ")+" < /dev/tty")
+	}
+	saved, err := stty("-g").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal settings: %w", err)
+	}
+	if err := stty("-icanon", "-echo", "min", "1").Run(); err != nil {
+		return nil, fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	fmt.Print(enterAltScreen)
+	return func() {
+		fmt.Print(leaveAltScreen)
+		stty(strings.TrimSpace(string(saved))).Run()
+	}, nil
+}
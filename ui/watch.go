@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// --watch draws on the alternate screen, like less or htop, so the shell's scrollback is left alone
+
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l" // also hides the cursor
+	leaveAltScreen = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+)
+
+func EnterAltScreen() {
+	fmt.Print(enterAltScreen)
+}
+
+func LeaveAltScreen() {
+	fmt.Print(leaveAltScreen)
+}
+
+// Capture returns what display prints, so a whole frame can be drawn at once without flicker
+func Capture(display func()) string {
+	var buf bytes.Buffer
+	prevOut := displayOut
+	displayOut = &buf
+	display()
+	displayOut = prevOut
+	return buf.String()
+}
+
+// DrawFrame replaces the screen with the frame and a status line
+func DrawFrame(frame, status string) {
+	fmt.Print(clearScreen + frame + "\n" + status)
+}
+
+// WatchStatus is the line under the view: when it was updated, the change since the previous refresh, and the last error
+func WatchStatus(updated time.Time, delta float64, hasPrev bool, interval time.Duration, err error) string {
+	status := Clr.Gray + "Last updated " + updated.Format("15:04:05") + Clr.Reset
+	if hasPrev {
+		switch {
+		case delta > 0:
+			status += "  " + Clr.Green + "+" + timeFmt(delta) + Clr.Reset + " since last refresh"
+		case delta < 0:
+			status += "  " + Clr.Red + "-" + timeFmt(-delta) + Clr.Reset + " since last refresh"
+		default:
+			status += Clr.Gray + "  no change since last refresh" + Clr.Reset
+		}
+	}
+	status += Clr.Gray + fmt.Sprintf("  (every %s, Ctrl+C to quit)", interval) + Clr.Reset
+	if err != nil {
+		status += "\n" + Clr.Red + "Refresh failed: " + err.Error() + Clr.Reset
+	}
+	return status
+}
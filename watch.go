@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sahaj-b/wakafetch/ui"
+)
+
+const minWatchInterval = 10 * time.Second
+
+// watchView redraws the view every --watch interval. A failed refresh keeps the last view on screen,
+// with the error under it, so a flaky connection doesn't blank the screen
+func watchView(config Config, profiles []apiProfile) {
+	interval := *config.watchFlag
+	if interval < minWatchInterval {
+		ui.Errorln("Invalid value for --watch: must be at least %s", minWatchInterval)
+	}
+	if *config.formatFlag != "text" {
+		ui.Errorln("--watch only works with the text format")
+	}
+	refreshEvery(interval)
+
+	// the first fetch happens before switching screens, so errors like a bad api key show up normally
+	v, err := fetchView(config, profiles)
+	if err != nil {
+		ui.Errorln(err.Error())
+	}
+
+	ui.EnterAltScreen()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		ui.LeaveAltScreen()
+		os.Exit(0)
+	}()
+
+	frame, updated := ui.Capture(v.display), time.Now()
+	ui.DrawFrame(frame, ui.WatchStatus(updated, 0, false, interval, nil))
+	delta := 0.0
+	for {
+		time.Sleep(interval)
+		next, err := fetchView(config, profiles)
+		if err == nil {
+			delta = next.total - v.total
+			v, frame, updated = next, ui.Capture(next.display), time.Now()
+		}
+		ui.DrawFrame(frame, ui.WatchStatus(updated, delta, true, interval, err))
+	}
+}